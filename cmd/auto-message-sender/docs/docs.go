@@ -15,20 +15,42 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/messages/sent": {
+        "/messages": {
             "get": {
-                "description": "Retrieve a paginated list of sent messages",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieve a paginated list of messages matching the filters. filters is a JSON array of {\"field\", \"operation\", \"value\", \"valueFrom\", \"valueTo\"} objects; see GET /messages/filters for the allowed fields and operations. Dates use the DD-MM-YYYY format.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "messages"
                 ],
-                "summary": "Get list of sent messages",
+                "summary": "Search messages",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "example": "[{\"field\":\"status\",\"operation\":\"equals\",\"value\":\"failed\"}]",
+                        "description": "Filters as a JSON array",
+                        "name": "filters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Field to sort by",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order",
+                        "name": "sortOrder",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "default": 1,
@@ -40,7 +62,7 @@ const docTemplate = `{
                         "type": "integer",
                         "default": 10,
                         "description": "Items per page",
-                        "name": "per_page",
+                        "name": "pageSize",
                         "in": "query"
                     }
                 ],
@@ -64,11 +86,67 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "post": {
+                "description": "Validate and store a new pending message to be sent by the scheduler once send_at has passed and before expires_at. Requests repeated with the same Idempotency-Key return the originally created message.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Enqueue a new message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Message to enqueue",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.CreateMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
             }
         },
-        "/scheduler/start": {
+        "/messages/batch": {
             "post": {
-                "description": "Start the automatic message sending scheduler",
+                "description": "Validate each message and store the valid ones in a single bulk write. Rejected items are reported by their index in the request.",
                 "consumes": [
                     "application/json"
                 ],
@@ -76,14 +154,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "scheduler"
+                    "messages"
+                ],
+                "summary": "Enqueue messages in bulk",
+                "parameters": [
+                    {
+                        "description": "Messages to enqueue",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.BatchCreateMessageRequest"
+                        }
+                    }
                 ],
-                "summary": "Start the message scheduler",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.SchedulerResponse"
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.BatchCreateMessageResponse"
                         }
                     },
                     "400": {
@@ -101,9 +190,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/scheduler/stop": {
-            "post": {
-                "description": "Stop the automatic message sending scheduler",
+        "/messages/dead-letter": {
+            "get": {
+                "description": "Retrieve a paginated list of messages that used up all of their retries, most recent first, with their error history",
                 "consumes": [
                     "application/json"
                 ],
@@ -111,14 +200,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "scheduler"
+                    "messages"
+                ],
+                "summary": "Get list of dead-lettered messages",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "per_page",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Stop the message scheduler",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.SchedulerResponse"
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.DeadLetterListResponse"
                         }
                     },
                     "400": {
@@ -135,99 +240,1245 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "gin.H": {
-            "type": "object",
-            "additionalProperties": {}
         },
-        "github_com_sinan_auto-message-sender_internal_models.Message": {
-            "type": "object",
-            "required": [
-                "content",
-                "to"
-            ],
-            "properties": {
-                "content": {
-                    "type": "string",
-                    "maxLength": 160
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "error": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "message_id": {
-                    "type": "string"
-                },
-                "retry_count": {
-                    "type": "integer"
-                },
-                "sent_at": {
-                    "type": "string"
-                },
-                "status": {
-                    "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageStatus"
-                },
-                "to": {
-                    "type": "string"
+        "/messages/dead-letter/requeue": {
+            "post": {
+                "description": "Move dead-lettered messages, selected by ID or by filter, back to pending with a fresh retry budget. At most MAX_BATCH_SIZE messages are requeued per request; an empty filter matches every dead-lettered message.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Requeue dead-lettered messages",
+                "parameters": [
+                    {
+                        "description": "Messages to requeue",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.RequeueDeadLetterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.RequeueDeadLetterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
                 }
             }
         },
-        "github_com_sinan_auto-message-sender_internal_models.MessageListResponse": {
-            "type": "object",
-            "properties": {
-                "messages": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Message"
+        "/messages/filters": {
+            "get": {
+                "description": "Describe the fields, operations and sort fields accepted by GET /messages",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "List message search filters",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/FilterMetadata"
+                        }
                     }
-                },
-                "page": {
-                    "type": "integer"
-                },
-                "per_page": {
-                    "type": "integer"
-                },
-                "total": {
-                    "type": "integer"
-                },
-                "total_pages": {
-                    "type": "integer"
                 }
             }
         },
-        "github_com_sinan_auto-message-sender_internal_models.MessageStatus": {
-            "type": "string",
-            "enum": [
-                "pending",
-                "sent",
-                "failed"
-            ],
-            "x-enum-varnames": [
-                "MessageStatusPending",
-                "MessageStatusSent",
-                "MessageStatusFailed"
-            ]
+        "/messages/provider/{messageId}": {
+            "get": {
+                "description": "Retrieve a sent message by the message ID the webhook provider returned. Recently sent messages are resolved through Redis before falling back to MongoDB.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get a message by the provider's message ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider message ID",
+                        "name": "messageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
         },
-        "github_com_sinan_auto-message-sender_internal_models.SchedulerResponse": {
-            "type": "object",
-            "properties": {
-                "is_active": {
-                    "type": "boolean"
-                },
-                "message": {
-                    "type": "string"
-                },
-                "started_at": {
-                    "type": "string"
-                },
-                "stopped_at": {
+        "/messages/sent": {
+            "get": {
+                "description": "Retrieve a list of sent messages, most recent first. Follow next_cursor to page through the list without skipping or repeating messages; page is only used when no cursor is given. The total count is included when include_total is true, which is the default without a cursor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get list of sent messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count all sent messages",
+                        "name": "include_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/{id}": {
+            "get": {
+                "description": "Retrieve a message, including dead-lettered ones, with its delivery attempts and error history",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancel a message that has not been picked up for sending yet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Cancel a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change the recipient, content or send time of a message that has not been picked up for sending yet. The send time must stay before the message's expires_at.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Edit a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.UpdateMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduler/config": {
+            "patch": {
+                "description": "Change the scheduler settings at runtime. A running ticker is reset without interrupting in-flight jobs, and the change is recorded in the scheduler logs. Other instances pick it up on their next leader lease renewal.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "Change the scheduler interval and batch size",
+                "parameters": [
+                    {
+                        "description": "Settings to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.SchedulerConfigRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.SchedulerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduler/schedules": {
+            "get": {
+                "description": "List the cron schedules the scheduler runs instead of the fixed interval",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "List named schedules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Schedule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduler/schedules/{name}": {
+            "put": {
+                "description": "Define a cron schedule, evaluated in an IANA time zone, with its own batch size and message filter. The cron expression has five fields, or six with leading seconds (e.g. \"*/30 * 8-19 * * MON-FRI\").",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "Create or replace a named schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule definition",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.ScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a cron schedule. When no enabled schedule is left, the scheduler falls back to the fixed interval.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "Delete a named schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduler/start": {
+            "post": {
+                "description": "Start the automatic message sending scheduler on all instances. Other instances follow within one leader lease renewal.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "Start the message scheduler",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.SchedulerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduler/status": {
+            "get": {
+                "description": "Report whether the scheduler is running on this instance, the current leader, its settings and live counters",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "Get the message scheduler status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.SchedulerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduler/stop": {
+            "post": {
+                "description": "Stop the automatic message sending scheduler on all instances. Other instances follow within one leader lease renewal.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "Stop the message scheduler",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.SchedulerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "FilterMetadata": {
+            "type": "object",
+            "properties": {
+                "filterableFields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FilterableField"
+                    }
+                },
+                "sortableFields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SortableField"
+                    }
+                }
+            }
+        },
+        "FilterableField": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "operations": {
+                    "description": "equals, contains, between, gt, lt vs.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "description": "string, number, date, boolean",
+                    "type": "string"
+                }
+            }
+        },
+        "SortableField": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "gin.H": {
+            "type": "object",
+            "additionalProperties": {}
+        },
+        "github_com_sinan_auto-message-sender_internal_models.BatchCreateMessageRequest": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.BatchMessageItem"
+                    }
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.BatchCreateMessageResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "already_enqueued": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.BatchItemError"
+                    }
+                },
+                "rejected": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.BatchItemError": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_validation.ValidationError"
+                    }
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.BatchMessageItem": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.CreateMessageRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.DeadLetterListResponse": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.DeadLetterMessage"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.DeadLetterMessage": {
+            "type": "object",
+            "required": [
+                "content",
+                "to"
+            ],
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.DeliveryAttempt"
+                    }
+                },
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "claim_id": {
+                    "type": "string"
+                },
+                "claimed_by": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string",
+                    "maxLength": 160
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dead_lettered_at": {
+                    "type": "string"
+                },
+                "deferred_reason": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageError"
+                    }
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "idempotency_key": {
+                    "type": "string"
+                },
+                "lease_expires_at": {
+                    "type": "string"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "retry_count": {
+                    "type": "integer"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageStatus"
+                },
+                "suppressed_reason": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.DeliveryAttempt": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "attempted_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "response_body": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.Message": {
+            "type": "object",
+            "required": [
+                "content",
+                "to"
+            ],
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.DeliveryAttempt"
+                    }
+                },
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "claim_id": {
+                    "type": "string"
+                },
+                "claimed_by": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string",
+                    "maxLength": 160
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deferred_reason": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageError"
+                    }
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "idempotency_key": {
+                    "type": "string"
+                },
+                "lease_expires_at": {
+                    "type": "string"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "retry_count": {
+                    "type": "integer"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageStatus"
+                },
+                "suppressed_reason": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.MessageError": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.MessageFilter": {
+            "type": "object",
+            "properties": {
+                "max_priority": {
+                    "type": "integer"
+                },
+                "min_priority": {
+                    "type": "integer"
+                },
+                "to_prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.MessageListResponse": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.Message"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.MessageStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "sending",
+                "sent",
+                "failed",
+                "cancelled",
+                "expired",
+                "suppressed"
+            ],
+            "x-enum-varnames": [
+                "MessageStatusPending",
+                "MessageStatusSending",
+                "MessageStatusSent",
+                "MessageStatusFailed",
+                "MessageStatusCancelled",
+                "MessageStatusExpired",
+                "MessageStatusSuppressed"
+            ]
+        },
+        "github_com_sinan_auto-message-sender_internal_models.RequeueDeadLetterRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageFilter"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.RequeueDeadLetterResponse": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "requeued": {
+                    "type": "integer"
+                },
+                "skipped_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.Schedule": {
+            "type": "object",
+            "properties": {
+                "batch_size": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "cron": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "filter": {
+                    "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageFilter"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.ScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "batch_size": {
+                    "type": "integer"
+                },
+                "cron": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "filter": {
+                    "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.MessageFilter"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.SchedulerConfigRequest": {
+            "type": "object",
+            "properties": {
+                "interval": {
+                    "type": "string"
+                },
+                "messages_per_interval": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.SchedulerResponse": {
+            "type": "object",
+            "properties": {
+                "in_flight_jobs": {
+                    "type": "integer"
+                },
+                "instance_id": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "last_tick_at": {
+                    "type": "string"
+                },
+                "leader": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "messages_per_interval": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "next_tick_at": {
+                    "type": "string"
+                },
+                "queue_backend": {
+                    "type": "string"
+                },
+                "schedules": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start_id": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "stopped_at": {
+                    "type": "string"
+                },
+                "stream_depth": {
+                    "type": "integer"
+                },
+                "worker_pool": {
+                    "$ref": "#/definitions/github_com_sinan_auto-message-sender_internal_models.WorkerPoolStats"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.UpdateMessageRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_models.WorkerPoolStats": {
+            "type": "object",
+            "properties": {
+                "active_workers": {
+                    "type": "integer"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "queue_capacity": {
+                    "type": "integer"
+                },
+                "queue_depth": {
+                    "type": "integer"
+                },
+                "rate_per_second": {
+                    "type": "integer"
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_sinan_auto-message-sender_internal_validation.ValidationError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
                     "type": "string"
                 }
             }
@@ -46,12 +46,24 @@ func main() {
 	defer redisClient.Close()
 
 	dataOps := dataOperations.New(mongoClient, redisClient, cfg)
+	if err := dataOps.EnsureIndexes(); err != nil {
+		log.Fatalf("Failed to create MongoDB indexes: %v", err)
+	}
 
-	webhookHandler := handlers.NewWebhookHandler(cfg, log)
+	webhookHandler := handlers.NewWebhookHandler(dataOps, cfg, log)
 	messageHandler := handlers.NewMessageHandler(dataOps, cfg, log)
 	schedulerHandler := handlers.NewSchedulerHandler(dataOps, cfg, log)
 	schedulerHandler.SetWebhookHandler(webhookHandler)
 
+	if active, err := dataOps.IsSchedulerActive(); err != nil {
+		log.WithError(err).Error("Failed to read scheduler state, scheduler stays stopped")
+	} else if active {
+		if err := schedulerHandler.Resume(); err != nil {
+			log.WithError(err).Error("Failed to resume scheduler")
+		}
+	}
+	schedulerHandler.Follow()
+
 	router := NewRouter(
 		cfg,
 		log,
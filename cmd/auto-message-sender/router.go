@@ -39,11 +39,26 @@ func NewRouter(
 		{
 			scheduler.POST("/start", schedulerHandler.StartScheduler)
 			scheduler.POST("/stop", schedulerHandler.StopScheduler)
+			scheduler.GET("/status", schedulerHandler.GetSchedulerStatus)
+			scheduler.PATCH("/config", schedulerHandler.UpdateSchedulerConfig)
+			scheduler.GET("/schedules", schedulerHandler.GetSchedules)
+			scheduler.PUT("/schedules/:name", schedulerHandler.SaveSchedule)
+			scheduler.DELETE("/schedules/:name", schedulerHandler.DeleteSchedule)
 		}
 
 		messages := api.Group("/messages")
 		{
+			messages.GET("", messageHandler.SearchMessages)
+			messages.POST("", messageHandler.CreateMessage)
+			messages.POST("/batch", messageHandler.CreateMessageBatch)
 			messages.GET("/sent", messageHandler.GetSentMessages)
+			messages.GET("/filters", messageHandler.GetMessageFilters)
+			messages.GET("/dead-letter", messageHandler.GetDeadLetterMessages)
+			messages.POST("/dead-letter/requeue", messageHandler.RequeueDeadLetterMessages)
+			messages.GET("/provider/:messageId", messageHandler.GetMessageByProviderID)
+			messages.GET("/:id", messageHandler.GetMessage)
+			messages.PATCH("/:id", messageHandler.UpdateMessage)
+			messages.DELETE("/:id", messageHandler.CancelMessage)
 		}
 	}
 
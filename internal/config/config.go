@@ -1,11 +1,28 @@
 package config
 
 import (
+	"github.com/google/uuid"
 	"os"
 	"strconv"
 	"time"
 )
 
+// Scheduler modes: the ticker sends MessagesPerInterval messages every
+// SchedulerInterval, the stream keeps the worker pool busy as long as
+// messages are due.
+const (
+	SchedulerModeTicker = "ticker"
+	SchedulerModeStream = "stream"
+)
+
+// Queue backends: with mongo the scheduler only finds due messages by
+// querying MongoDB, with redis_stream new messages are also pushed to a Redis
+// stream that the leader consumes.
+const (
+	QueueBackendMongo       = "mongo"
+	QueueBackendRedisStream = "redis_stream"
+)
+
 type Config struct {
 	Server  ServerConfig
 	MongoDB MongoDBConfig
@@ -40,9 +57,29 @@ type WebhookConfig struct {
 
 type AppConfig struct {
 	Environment         string
+	InstanceID          string
+	SchedulerMode       string
 	SchedulerInterval   time.Duration
+	StreamPollInterval  time.Duration
+	QueueBackend        string
 	MessagesPerInterval int
 	MaxRetryCount       int
+	WorkerConcurrency   int
+	WorkerQueueSize     int
+	SendRatePerSecond   int
+	GlobalSendRate      int
+	GlobalSendBurst     int
+	RetryBaseDelay      time.Duration
+	RetryMaxDelay       time.Duration
+	MaxBatchSize        int
+	IdempotencyKeyTTL   time.Duration
+	MessageLeaseTTL     time.Duration
+	LeaderLeaseTTL      time.Duration
+	QuietHoursStart     string
+	QuietHoursEnd       string
+	RecipientMaxSends   int
+	RecipientWindow     time.Duration
+	DuplicateWindow     time.Duration
 }
 
 func Load() *Config {
@@ -69,13 +106,40 @@ func Load() *Config {
 		},
 		App: AppConfig{
 			Environment:         getEnv("ENVIRONMENT", "development"),
+			InstanceID:          getEnv("INSTANCE_ID", defaultInstanceID()),
+			SchedulerMode:       getEnv("SCHEDULER_MODE", SchedulerModeTicker),
 			SchedulerInterval:   getDurationEnv("SCHEDULER_INTERVAL", 2*time.Minute),
+			StreamPollInterval:  getDurationEnv("STREAM_POLL_INTERVAL", time.Second),
+			QueueBackend:        getEnv("QUEUE_BACKEND", QueueBackendMongo),
 			MessagesPerInterval: getIntEnv("MESSAGES_PER_INTERVAL", 2),
 			MaxRetryCount:       getIntEnv("MAX_RETRY_COUNT", 3),
+			WorkerConcurrency:   getIntEnv("WORKER_CONCURRENCY", 10),
+			WorkerQueueSize:     getIntEnv("WORKER_QUEUE_SIZE", 100),
+			SendRatePerSecond:   getIntEnv("SEND_RATE_PER_SECOND", 0),
+			GlobalSendRate:      getIntEnv("GLOBAL_SEND_RATE", 0),
+			GlobalSendBurst:     getIntEnv("GLOBAL_SEND_BURST", 0),
+			RetryBaseDelay:      getDurationEnv("RETRY_BASE_DELAY", time.Minute),
+			RetryMaxDelay:       getDurationEnv("RETRY_MAX_DELAY", time.Hour),
+			MaxBatchSize:        getIntEnv("MAX_BATCH_SIZE", 5000),
+			IdempotencyKeyTTL:   getDurationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+			MessageLeaseTTL:     getDurationEnv("MESSAGE_LEASE_TTL", 5*time.Minute),
+			LeaderLeaseTTL:      getDurationEnv("LEADER_LEASE_TTL", 15*time.Second),
+			QuietHoursStart:     getEnv("QUIET_HOURS_START", "21:00"),
+			QuietHoursEnd:       getEnv("QUIET_HOURS_END", "08:00"),
+			RecipientMaxSends:   getIntEnv("RECIPIENT_MAX_SENDS", 0),
+			RecipientWindow:     getDurationEnv("RECIPIENT_WINDOW", time.Hour),
+			DuplicateWindow:     getDurationEnv("DUPLICATE_WINDOW", 10*time.Minute),
 		},
 	}
 }
 
+func defaultInstanceID() string {
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return uuid.NewString()
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -2,14 +2,19 @@ package dataOperations
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/sinan/auto-message-sender/internal/models"
+	"github.com/sinan/auto-message-sender/pkg/redisdb"
 	"time"
 )
 
-func (do *DataOperations) CacheMessage(messageID string, sentAt time.Time) error {
+// CacheMessage maps the provider's message ID to our own message ID.
+func (do *DataOperations) CacheMessage(id, messageID string, sentAt time.Time) error {
 	key := fmt.Sprintf("message_sent:%s", messageID)
 	cachedMsg := models.CachedMessage{
+		ID:        id,
 		MessageID: messageID,
 		SentAt:    sentAt,
 	}
@@ -17,11 +22,16 @@ func (do *DataOperations) CacheMessage(messageID string, sentAt time.Time) error
 	return do.redis.SetJSON(context.Background(), key, cachedMsg, 24*time.Hour)
 }
 
+// GetCachedMessage returns nil without an error when the provider's message
+// ID is not cached.
 func (do *DataOperations) GetCachedMessage(messageID string) (*models.CachedMessage, error) {
 	key := fmt.Sprintf("message_sent:%s", messageID)
 
 	var cachedMsg models.CachedMessage
 	err := do.redis.GetJSON(context.Background(), key, &cachedMsg)
+	if redisdb.IsNotFound(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -33,3 +43,79 @@ func (do *DataOperations) IsCachedMessage(messageID string) (bool, error) {
 	key := fmt.Sprintf("message_sent:%s", messageID)
 	return do.redis.Exists(context.Background(), key)
 }
+
+// TakeRecipientSlot counts a send to the recipient against
+// RecipientMaxSends per RecipientWindow. When the limit is reached it
+// reports how long until the recipient can be messaged again.
+func (do *DataOperations) TakeRecipientSlot(to string) (bool, time.Duration, error) {
+	return do.redis.TakeWindowSlot(context.Background(), recipientSendsKey(to), do.config.App.RecipientMaxSends, do.config.App.RecipientWindow)
+}
+
+// ReturnRecipientSlot gives back a slot taken for a send that failed.
+func (do *DataOperations) ReturnRecipientSlot(to string) error {
+	return do.redis.ReturnWindowSlot(context.Background(), recipientSendsKey(to))
+}
+
+func recipientSendsKey(to string) string {
+	return fmt.Sprintf("recipient_sends:%s", to)
+}
+
+// ClaimDuplicateWindow reports whether the message may be sent, i.e. no other
+// message with the same recipient and content was sent within
+// DuplicateWindow. A message that claimed the window before, for example on
+// an earlier attempt, may still be sent.
+func (do *DataOperations) ClaimDuplicateWindow(to, content, messageID string) (bool, error) {
+	key := duplicateWindowKey(to, content)
+
+	claimed, err := do.redis.SetNX(context.Background(), key, messageID, do.config.App.DuplicateWindow)
+	if err != nil || claimed {
+		return claimed, err
+	}
+
+	owner, err := do.redis.Get(context.Background(), key)
+	if redisdb.IsNotFound(err) {
+		return do.redis.SetNX(context.Background(), key, messageID, do.config.App.DuplicateWindow)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return owner == messageID, nil
+}
+
+// ReleaseDuplicateWindow frees the window claimed by messageID when its send
+// failed, so that an identical message is not suppressed in its favour. A
+// window claimed by another message is left alone.
+func (do *DataOperations) ReleaseDuplicateWindow(to, content, messageID string) error {
+	return do.redis.ReleaseLock(context.Background(), duplicateWindowKey(to, content), messageID)
+}
+
+func duplicateWindowKey(to, content string) string {
+	sum := sha256.Sum256([]byte(to + "\n" + content))
+	return fmt.Sprintf("message_dedup:%s", hex.EncodeToString(sum[:]))
+}
+
+func (do *DataOperations) CacheIdempotencyKey(idempotencyKey, messageID string) error {
+	key := fmt.Sprintf("idempotency_key:%s", idempotencyKey)
+	record := models.IdempotencyRecord{
+		MessageID: messageID,
+	}
+
+	return do.redis.SetJSON(context.Background(), key, record, do.config.App.IdempotencyKeyTTL)
+}
+
+// GetIdempotencyRecord returns nil without an error when the key is unknown or has expired.
+func (do *DataOperations) GetIdempotencyRecord(idempotencyKey string) (*models.IdempotencyRecord, error) {
+	key := fmt.Sprintf("idempotency_key:%s", idempotencyKey)
+
+	var record models.IdempotencyRecord
+	err := do.redis.GetJSON(context.Background(), key, &record)
+	if redisdb.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
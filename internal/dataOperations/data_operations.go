@@ -19,3 +19,13 @@ func New(mongo *mongodb.MongoDB, redis *redisdb.RedisDB, config *config.Config)
 		config: config,
 	}
 }
+
+func (do *DataOperations) EnsureIndexes() error {
+	if err := do.EnsureMessageIndexes(); err != nil {
+		return err
+	}
+	if err := do.EnsureDeadLetterIndexes(); err != nil {
+		return err
+	}
+	return do.EnsureSchedulerLogIndexes()
+}
@@ -0,0 +1,190 @@
+package dataOperations
+
+import (
+	"github.com/sinan/auto-message-sender/internal/models"
+	"github.com/sinan/auto-message-sender/pkg/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const DeadLetterCollection = "messages_dead_letter"
+
+// MoveToDeadLetter marks the message as failed, records the last failure and
+// moves it to the dead-letter collection. If the move is interrupted the
+// message stays in the messages collection with the failed status.
+func (do *DataOperations) MoveToDeadLetter(message models.Message, failure models.MessageError) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.MessageStatusFailed,
+			"error":      failure.Error,
+			"updated_at": time.Now(),
+		},
+		"$inc":  bson.M{"retry_count": 1},
+		"$push": bson.M{"error_history": failure},
+		"$unset": bson.M{
+			"claimed_by":       "",
+			"claim_id":         "",
+			"lease_expires_at": "",
+			"next_attempt_at":  "",
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	failed, err := mongodb.FindOneAndUpdate[models.Message](do.mongo, MessagesCollection, claimedMessageFilter(message), update, opts)
+	if err != nil {
+		return err
+	}
+
+	if failed == nil {
+		return ErrClaimLost
+	}
+
+	deadLetter := models.DeadLetterMessage{
+		Message:        *failed,
+		DeadLetteredAt: time.Now(),
+	}
+
+	err = mongodb.InsertOne(do.mongo, DeadLetterCollection, deadLetter)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	return mongodb.DeleteOne(do.mongo, MessagesCollection, message.ID)
+}
+
+func (do *DataOperations) EnsureDeadLetterIndexes() error {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "idempotency_key", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	}
+
+	return mongodb.CreateIndexes(do.mongo, DeadLetterCollection, indexModels)
+}
+
+func (do *DataOperations) GetDeadLetterMessage(messageID string) (*models.DeadLetterMessage, error) {
+	return mongodb.GetOneById[models.DeadLetterMessage](do.mongo, DeadLetterCollection, messageID)
+}
+
+// GetDeadLetterMessageByIdempotencyKey returns nil without an error when no
+// dead-lettered message uses the key.
+func (do *DataOperations) GetDeadLetterMessageByIdempotencyKey(idempotencyKey string) (*models.DeadLetterMessage, error) {
+	filter := bson.M{"idempotency_key": idempotencyKey}
+	return mongodb.GetOneWithFilter[models.DeadLetterMessage](do.mongo, DeadLetterCollection, filter)
+}
+
+func (do *DataOperations) GetDeadLetterMessages(page, perPage int) ([]models.DeadLetterMessage, int64, error) {
+	filter := bson.M{}
+
+	total, err := mongodb.Count(do.mongo, DeadLetterCollection, filter, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * perPage
+	opts := options.Find().
+		SetSort(bson.D{{Key: "dead_lettered_at", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(perPage))
+
+	messages, err := mongodb.Query[models.DeadLetterMessage](do.mongo, DeadLetterCollection, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// RequeueDeadLetterMessages moves up to limit dead-lettered messages that
+// match the IDs, or otherwise the filter, back to the pending queue with a
+// fresh retry budget. The error history is kept. It returns the IDs of the
+// requeued messages and of those that stayed dead-lettered because they
+// could not be written back, e.g. because a pending message now uses the same
+// idempotency key.
+func (do *DataOperations) RequeueDeadLetterMessages(ids []string, messageFilter *models.MessageFilter, limit int) ([]string, []string, error) {
+	filter := bson.M{}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	} else if messageFilter != nil {
+		applyMessageFilter(filter, *messageFilter)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "dead_lettered_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	deadLetters, err := mongodb.Query[models.DeadLetterMessage](do.mongo, DeadLetterCollection, filter, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(deadLetters) == 0 {
+		return []string{}, []string{}, nil
+	}
+
+	now := time.Now()
+	candidates := make([]string, len(deadLetters))
+	writeModels := make([]mongo.WriteModel, len(deadLetters))
+	for i, deadLetter := range deadLetters {
+		message := deadLetter.Message
+		message.Status = models.MessageStatusPending
+		message.RetryCount = 0
+		message.NextAttemptAt = nil
+		message.ClaimedBy = nil
+		message.ClaimID = nil
+		message.LeaseExpiresAt = nil
+		message.DeferredReason = nil
+		if message.SendAt == nil || message.SendAt.Before(now) {
+			message.SendAt = &now
+		}
+
+		candidates[i] = message.ID
+		writeModels[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": message.ID}).
+			SetReplacement(message).
+			SetUpsert(true)
+	}
+
+	// Duplicate key errors are only counted by BulkWrite, so the messages
+	// that were written back are looked up before anything is deleted. A copy
+	// left failed by an interrupted MoveToDeadLetter does not count; one that
+	// was already claimed since the write does.
+	if _, err := mongodb.BulkWrite(do.mongo, MessagesCollection, writeModels); err != nil {
+		return nil, nil, err
+	}
+
+	writtenFilter := bson.M{
+		"_id":    bson.M{"$in": candidates},
+		"status": bson.M{"$ne": models.MessageStatusFailed},
+	}
+	written, err := mongodb.Query[models.Message](do.mongo, MessagesCollection, writtenFilter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isWritten := make(map[string]bool, len(written))
+	for _, message := range written {
+		isWritten[message.ID] = true
+	}
+
+	requeued := make([]string, 0, len(candidates))
+	skipped := make([]string, 0)
+	for _, id := range candidates {
+		if isWritten[id] {
+			requeued = append(requeued, id)
+		} else {
+			skipped = append(skipped, id)
+		}
+	}
+
+	if len(requeued) > 0 {
+		if err := mongodb.DeleteAll(do.mongo, DeadLetterCollection, bson.M{"_id": bson.M{"$in": requeued}}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return requeued, skipped, nil
+}
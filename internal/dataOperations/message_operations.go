@@ -1,47 +1,427 @@
 package dataOperations
 
 import (
-	"context"
+	"errors"
 	"github.com/sinan/auto-message-sender/internal/models"
 	"github.com/sinan/auto-message-sender/pkg/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
 	"time"
 )
 
 const MessagesCollection = "messages"
 
+var (
+	ErrDuplicateMessage  = errors.New("message already exists")
+	ErrMessageNotFound   = errors.New("message not found")
+	ErrMessageNotPending = errors.New("message is no longer pending")
+	ErrSendAtAfterExpiry = errors.New("send_at must be before the message's expires_at")
+	ErrClaimLost         = errors.New("message is no longer claimed by this instance")
+)
+
+func (do *DataOperations) EnsureMessageIndexes() error {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "idempotency_key", Value: 1}},
+			Options: options.Index().SetName("idempotency_key_unique").SetUnique(true).SetSparse(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "priority", Value: -1},
+				{Key: "send_at", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "lease_expires_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "sent_at", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+		},
+		{
+			Keys:    bson.D{{Key: "message_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	}
+
+	return mongodb.CreateIndexes(do.mongo, MessagesCollection, indexModels)
+}
+
 func (do *DataOperations) CreateMessage(message *models.Message) error {
-	return mongodb.InsertOne(do.mongo, MessagesCollection, message)
+	err := mongodb.InsertOne(do.mongo, MessagesCollection, message)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateMessage
+	}
+	return err
+}
+
+// CreateMessages inserts the messages in a single unordered bulk write and
+// returns how many of them were skipped because their ID already exists.
+func (do *DataOperations) CreateMessages(messages []*models.Message) (int, error) {
+	writeModels := make([]mongo.WriteModel, len(messages))
+	for i, message := range messages {
+		writeModels[i] = mongo.NewInsertOneModel().SetDocument(message)
+	}
+
+	return mongodb.BulkWrite(do.mongo, MessagesCollection, writeModels)
+}
+
+// ClaimPendingMessages atomically moves up to limit due messages to the
+// sending status and leases them to owner, so that no other instance picks
+// them up. Messages claimed before an error are returned along with it.
+func (do *DataOperations) ClaimPendingMessages(owner string, limit int, messageFilter models.MessageFilter) ([]models.Message, error) {
+	opts := options.FindOneAndUpdate().
+		SetSort(pendingMessagesSort()).
+		SetReturnDocument(options.After)
+
+	messages := make([]models.Message, 0, limit)
+	for len(messages) < limit {
+		now := time.Now()
+		update := bson.M{
+			"$set": bson.M{
+				"status":           models.MessageStatusSending,
+				"claimed_by":       owner,
+				"claim_id":         primitive.NewObjectID().Hex(),
+				"lease_expires_at": now.Add(do.config.App.MessageLeaseTTL),
+				"updated_at":       now,
+			},
+		}
+
+		filter := do.duePendingFilter(now)
+		applyMessageFilter(filter, messageFilter)
+
+		message, err := mongodb.FindOneAndUpdate[models.Message](do.mongo, MessagesCollection, filter, update, opts)
+		if err != nil {
+			return messages, err
+		}
+
+		if message == nil {
+			break
+		}
+
+		messages = append(messages, *message)
+	}
+
+	return messages, nil
+}
+
+// ClaimMessage claims a single message like ClaimPendingMessages does. It
+// returns nil without an error when the message is not due or not pending.
+func (do *DataOperations) ClaimMessage(owner string, messageID string) (*models.Message, error) {
+	now := time.Now()
+	filter := do.duePendingFilter(now)
+	filter["_id"] = messageID
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":           models.MessageStatusSending,
+			"claimed_by":       owner,
+			"claim_id":         primitive.NewObjectID().Hex(),
+			"lease_expires_at": now.Add(do.config.App.MessageLeaseTTL),
+			"updated_at":       now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	return mongodb.FindOneAndUpdate[models.Message](do.mongo, MessagesCollection, filter, update, opts)
+}
+
+// ReleaseExpiredClaims returns messages whose lease ran out, for example
+// because the owning instance crashed mid-send, to the pending queue.
+func (do *DataOperations) ReleaseExpiredClaims() (int64, error) {
+	now := time.Now()
+	filter := bson.M{
+		"status":           models.MessageStatusSending,
+		"lease_expires_at": bson.M{"$lte": now},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.MessageStatusPending,
+			"updated_at": now,
+		},
+		"$unset": bson.M{
+			"claimed_by":       "",
+			"claim_id":         "",
+			"lease_expires_at": "",
+		},
+	}
+
+	return mongodb.UpdateMany(do.mongo, MessagesCollection, filter, update)
+}
+
+// ReleaseClaims returns messages claimed by owner that were never sent to the
+// pending queue.
+func (do *DataOperations) ReleaseClaims(owner string, messageIDs []string) (int64, error) {
+	filter := bson.M{
+		"_id":        bson.M{"$in": messageIDs},
+		"status":     models.MessageStatusSending,
+		"claimed_by": owner,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.MessageStatusPending,
+			"updated_at": time.Now(),
+		},
+		"$unset": bson.M{
+			"claimed_by":       "",
+			"claim_id":         "",
+			"lease_expires_at": "",
+		},
+	}
+
+	return mongodb.UpdateMany(do.mongo, MessagesCollection, filter, update)
+}
+
+// RenewClaim extends the lease of a claimed message before it is sent. It
+// returns ErrClaimLost when the lease ran out in the meantime and the message
+// was released or claimed again.
+func (do *DataOperations) RenewClaim(message models.Message) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"lease_expires_at": now.Add(do.config.App.MessageLeaseTTL),
+			"updated_at":       now,
+		},
+	}
+
+	return do.updateClaimedMessage(message, update)
 }
 
-func (do *DataOperations) GetPendingMessages(limit int) ([]models.Message, error) {
+// updateClaimedMessage applies update only while the message is still held by
+// the claim it was handed out with. Once its lease runs out and it is claimed
+// again, even by the same instance, the old claim no longer matches and
+// ErrClaimLost is returned.
+func (do *DataOperations) updateClaimedMessage(message models.Message, update bson.M) error {
+	matched, err := mongodb.UpdateOneWithFilter(do.mongo, MessagesCollection, claimedMessageFilter(message), update)
+	if err != nil {
+		return err
+	}
+
+	if matched == 0 {
+		return ErrClaimLost
+	}
+
+	return nil
+}
+
+func claimedMessageFilter(message models.Message) bson.M {
+	return bson.M{
+		"_id":        message.ID,
+		"status":     models.MessageStatusSending,
+		"claimed_by": message.ClaimedBy,
+		"claim_id":   message.ClaimID,
+	}
+}
+
+// ExpirePendingMessages moves pending messages whose expires_at has passed
+// to the expired status, so that they are never sent.
+func (do *DataOperations) ExpirePendingMessages() (int64, error) {
+	now := time.Now()
 	filter := bson.M{
+		"status":     models.MessageStatusPending,
+		"expires_at": bson.M{"$lte": now},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.MessageStatusExpired,
+			"updated_at": now,
+		},
+		"$unset": bson.M{
+			"next_attempt_at": "",
+			"deferred_reason": "",
+		},
+	}
+
+	return mongodb.UpdateMany(do.mongo, MessagesCollection, filter, update)
+}
+
+// DeferMessage releases a claimed message back to the pending queue with a
+// later send_at instead of sending it now.
+func (do *DataOperations) DeferMessage(message models.Message, until time.Time, reason string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":          models.MessageStatusPending,
+			"send_at":         until,
+			"deferred_reason": reason,
+			"updated_at":      time.Now(),
+		},
+		"$unset": bson.M{
+			"claimed_by":       "",
+			"claim_id":         "",
+			"lease_expires_at": "",
+		},
+	}
+
+	return do.updateClaimedMessage(message, update)
+}
+
+// SuppressMessage releases a claimed message with the suppressed status, so
+// that it is never sent.
+func (do *DataOperations) SuppressMessage(message models.Message, reason string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":            models.MessageStatusSuppressed,
+			"suppressed_reason": reason,
+			"updated_at":        time.Now(),
+		},
+		"$unset": bson.M{
+			"claimed_by":       "",
+			"claim_id":         "",
+			"lease_expires_at": "",
+			"next_attempt_at":  "",
+		},
+	}
+
+	return do.updateClaimedMessage(message, update)
+}
+
+// ScheduleRetry returns a message whose send failed to the pending queue,
+// to be attempted again once nextAttemptAt has passed.
+func (do *DataOperations) ScheduleRetry(message models.Message, failure models.MessageError, nextAttemptAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":          models.MessageStatusPending,
+			"error":           failure.Error,
+			"next_attempt_at": nextAttemptAt,
+			"updated_at":      time.Now(),
+		},
+		"$inc":  bson.M{"retry_count": 1},
+		"$push": bson.M{"error_history": failure},
+		"$unset": bson.M{
+			"claimed_by":       "",
+			"claim_id":         "",
+			"lease_expires_at": "",
+		},
+	}
+
+	return do.updateClaimedMessage(message, update)
+}
+
+func (do *DataOperations) AddDeliveryAttempts(messageID string, attempts []models.DeliveryAttempt) error {
+	filter := bson.M{"_id": messageID}
+	update := bson.M{
+		"$push": bson.M{"attempts": bson.M{"$each": attempts}},
+	}
+
+	_, err := mongodb.UpdateOneWithFilter(do.mongo, MessagesCollection, filter, update)
+	return err
+}
+
+// duePendingFilter matches pending messages whose send_at and, for retries,
+// next_attempt_at have passed and whose expires_at has not. Messages without
+// these fields are due.
+func (do *DataOperations) duePendingFilter(now time.Time) bson.M {
+	return bson.M{
 		"status":      models.MessageStatusPending,
 		"retry_count": bson.M{"$lt": do.config.App.MaxRetryCount},
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"send_at": nil},
+				bson.M{"send_at": bson.M{"$lte": now}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"next_attempt_at": nil},
+				bson.M{"next_attempt_at": bson.M{"$lte": now}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"expires_at": nil},
+				bson.M{"expires_at": bson.M{"$gt": now}},
+			}},
+		},
 	}
+}
 
+func applyMessageFilter(filter bson.M, messageFilter models.MessageFilter) {
+	priority := bson.M{}
+	if messageFilter.MinPriority != nil {
+		priority["$gte"] = *messageFilter.MinPriority
+	}
+	if messageFilter.MaxPriority != nil {
+		priority["$lte"] = *messageFilter.MaxPriority
+	}
+	if len(priority) > 0 {
+		filter["priority"] = priority
+	}
+
+	if messageFilter.ToPrefix != "" {
+		filter["to"] = bson.M{"$regex": "^" + regexp.QuoteMeta(messageFilter.ToPrefix)}
+	}
+}
+
+// pendingMessagesSort fills a batch from the highest priority down and puts
+// the most overdue messages first within a priority. send_at defaults to the
+// creation time, so messages that were not scheduled keep their FIFO order.
+func pendingMessagesSort() bson.D {
+	return bson.D{
+		{Key: "priority", Value: -1},
+		{Key: "send_at", Value: 1},
+		{Key: "created_at", Value: 1},
+	}
+}
+
+func (do *DataOperations) CountSentMessages() (int64, error) {
+	filter := bson.M{"status": models.MessageStatusSent}
+	return mongodb.Count(do.mongo, MessagesCollection, filter, nil)
+}
+
+func (do *DataOperations) GetSentMessages(page, perPage int) ([]models.Message, error) {
+	filter := bson.M{"status": models.MessageStatusSent}
+
+	skip := (page - 1) * perPage
 	opts := options.Find().
-		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSort(sentMessagesSort()).
+		SetSkip(int64(skip)).
+		SetLimit(int64(perPage))
+
+	return mongodb.Query[models.Message](do.mongo, MessagesCollection, filter, opts)
+}
+
+// GetSentMessagesAfter returns the page of sent messages that follows the
+// cursor. Unlike GetSentMessages it does not skip over earlier pages and is
+// not affected by messages sent while paging.
+func (do *DataOperations) GetSentMessagesAfter(cursor models.SentMessagesCursor, limit int) ([]models.Message, error) {
+	filter := bson.M{
+		"status": models.MessageStatusSent,
+		"$or": bson.A{
+			bson.M{"sent_at": bson.M{"$lt": cursor.SentAt}},
+			bson.M{"sent_at": cursor.SentAt, "_id": bson.M{"$lt": cursor.ID}},
+		},
+	}
+
+	opts := options.Find().
+		SetSort(sentMessagesSort()).
 		SetLimit(int64(limit))
 
 	return mongodb.Query[models.Message](do.mongo, MessagesCollection, filter, opts)
 }
 
-func (do *DataOperations) GetSentMessages(page, perPage int) ([]models.Message, int64, error) {
-	filter := bson.M{"status": models.MessageStatusSent}
+func sentMessagesSort() bson.D {
+	return bson.D{
+		{Key: "sent_at", Value: -1},
+		{Key: "_id", Value: -1},
+	}
+}
 
+func (do *DataOperations) SearchMessages(filter bson.M, opts *options.FindOptions) ([]models.Message, int64, error) {
 	total, err := mongodb.Count(do.mongo, MessagesCollection, filter, nil)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	skip := (page - 1) * perPage
-	opts := options.Find().
-		SetSort(bson.D{{Key: "sent_at", Value: -1}}).
-		SetSkip(int64(skip)).
-		SetLimit(int64(perPage))
-
 	messages, err := mongodb.Query[models.Message](do.mongo, MessagesCollection, filter, opts)
 	if err != nil {
 		return nil, 0, err
@@ -50,12 +430,18 @@ func (do *DataOperations) GetSentMessages(page, perPage int) ([]models.Message,
 	return messages, total, nil
 }
 
-func (do *DataOperations) UpdateMessageStatus(messageID string, status models.MessageStatus, webhookMessageID *string, errorMsg *string) error {
+// UpdateMessageStatus releases a claimed message with its final status.
+func (do *DataOperations) UpdateMessageStatus(message models.Message, status models.MessageStatus, webhookMessageID *string) error {
 	update := bson.M{
 		"$set": bson.M{
 			"status":     status,
 			"updated_at": time.Now(),
 		},
+		"$unset": bson.M{
+			"claimed_by":       "",
+			"claim_id":         "",
+			"lease_expires_at": "",
+		},
 	}
 
 	if status == models.MessageStatusSent && webhookMessageID != nil {
@@ -63,29 +449,124 @@ func (do *DataOperations) UpdateMessageStatus(messageID string, status models.Me
 		update["$set"].(bson.M)["message_id"] = *webhookMessageID
 	}
 
-	if status == models.MessageStatusFailed {
-		update["$inc"] = bson.M{"retry_count": 1}
-		if errorMsg != nil {
-			update["$set"].(bson.M)["error"] = *errorMsg
+	return do.updateClaimedMessage(message, update)
+}
+
+func (do *DataOperations) GetMessageByID(messageID string) (*models.Message, error) {
+	return mongodb.GetOneById[models.Message](do.mongo, MessagesCollection, messageID)
+}
+
+func (do *DataOperations) GetMessageByIdempotencyKey(idempotencyKey string) (*models.Message, error) {
+	filter := bson.M{"idempotency_key": idempotencyKey}
+	return mongodb.GetOneWithFilter[models.Message](do.mongo, MessagesCollection, filter)
+}
+
+// GetMessageByProviderID looks a message up by the message ID returned by
+// the webhook provider.
+func (do *DataOperations) GetMessageByProviderID(messageID string) (*models.Message, error) {
+	filter := bson.M{"message_id": messageID}
+	return mongodb.GetOneWithFilter[models.Message](do.mongo, MessagesCollection, filter)
+}
+
+// CancelMessage cancels a message that is still pending. It returns
+// ErrMessageNotFound or ErrMessageNotPending when the message cannot be
+// cancelled.
+func (do *DataOperations) CancelMessage(messageID string) (*models.Message, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.MessageStatusCancelled,
+			"cancelled_at": now,
+			"updated_at":   now,
+		},
+		"$unset": bson.M{
+			"next_attempt_at": "",
+			"deferred_reason": "",
+		},
+	}
+
+	return do.updatePendingMessage(messageID, nil, update)
+}
+
+// EditMessage changes the recipient, content or send time of a message that
+// is still pending. A new send time replaces any quiet hours deferral.
+func (do *DataOperations) EditMessage(messageID string, request models.UpdateMessageRequest) (*models.Message, error) {
+	set := bson.M{"updated_at": time.Now()}
+	update := bson.M{"$set": set}
+
+	if request.To != nil {
+		set["to"] = *request.To
+	}
+	if request.Content != nil {
+		set["content"] = *request.Content
+	}
+	// A message must not be pushed past its expiry, where it would expire
+	// instead of being sent.
+	var condition bson.M
+	if request.SendAt != nil {
+		set["send_at"] = *request.SendAt
+		update["$unset"] = bson.M{"deferred_reason": ""}
+		condition = bson.M{"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": *request.SendAt}},
+		}}
+	}
+
+	message, err := do.updatePendingMessage(messageID, condition, update)
+	if errors.Is(err, ErrMessageNotPending) && condition != nil {
+		existing, getErr := do.GetMessageByID(messageID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		if existing != nil && existing.Status == models.MessageStatusPending {
+			return nil, ErrSendAtAfterExpiry
 		}
 	}
 
-	filter := bson.M{"_id": messageID}
-	_, err := do.mongo.GetClient()
+	return message, err
+}
+
+// updatePendingMessage applies the update only while the message is pending
+// and matches condition, if given, so it cannot race with a scheduler
+// claiming the message.
+func (do *DataOperations) updatePendingMessage(messageID string, condition bson.M, update bson.M) (*models.Message, error) {
+	filter := bson.M{
+		"_id":    messageID,
+		"status": models.MessageStatusPending,
+	}
+	for key, value := range condition {
+		filter[key] = value
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	message, err := mongodb.FindOneAndUpdate[models.Message](do.mongo, MessagesCollection, filter, update, opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	client, err := do.mongo.GetClient()
+	if message != nil {
+		return message, nil
+	}
+
+	return nil, do.notPendingError(messageID)
+}
+
+func (do *DataOperations) notPendingError(messageID string) error {
+	existing, err := do.GetMessageByID(messageID)
 	if err != nil {
 		return err
 	}
+	if existing != nil {
+		return ErrMessageNotPending
+	}
 
-	collection := client.Database(do.mongo.DBName).Collection(MessagesCollection)
-	_, err = collection.UpdateOne(context.Background(), filter, update)
-	return err
-}
+	deadLetter, err := do.GetDeadLetterMessage(messageID)
+	if err != nil {
+		return err
+	}
+	if deadLetter != nil {
+		return ErrMessageNotPending
+	}
 
-func (do *DataOperations) GetMessageByID(messageID string) (*models.Message, error) {
-	return mongodb.GetOneById[models.Message](do.mongo, MessagesCollection, messageID)
+	return ErrMessageNotFound
 }
@@ -0,0 +1,45 @@
+package dataOperations
+
+import (
+	"context"
+	"github.com/sinan/auto-message-sender/pkg/redisdb"
+	"time"
+)
+
+const (
+	messageQueueStream = "message_queue"
+	messageQueueGroup  = "scheduler"
+)
+
+// EnqueueMessages pushes the message IDs to the Redis stream. MongoDB stays
+// the system of record: a stream entry only tells the scheduler where to look.
+func (do *DataOperations) EnqueueMessages(messageIDs []string) error {
+	entries := make([]map[string]interface{}, len(messageIDs))
+	for i, messageID := range messageIDs {
+		entries[i] = map[string]interface{}{"message_id": messageID}
+	}
+
+	return do.redis.StreamAdd(context.Background(), messageQueueStream, entries)
+}
+
+func (do *DataOperations) EnsureMessageQueueGroup() error {
+	return do.redis.EnsureConsumerGroup(context.Background(), messageQueueStream, messageQueueGroup)
+}
+
+func (do *DataOperations) ReadMessageQueue(consumer string, count int, block time.Duration) ([]redisdb.StreamEntry, error) {
+	return do.redis.StreamReadGroup(context.Background(), messageQueueStream, messageQueueGroup, consumer, int64(count), block)
+}
+
+// ReclaimMessageQueue takes over entries another consumer read but never
+// acknowledged, for example because its instance crashed.
+func (do *DataOperations) ReclaimMessageQueue(consumer string, minIdle time.Duration, count int) ([]redisdb.StreamEntry, error) {
+	return do.redis.StreamAutoClaim(context.Background(), messageQueueStream, messageQueueGroup, consumer, minIdle, int64(count))
+}
+
+func (do *DataOperations) AckMessageQueue(entryIDs ...string) error {
+	return do.redis.StreamAck(context.Background(), messageQueueStream, messageQueueGroup, entryIDs...)
+}
+
+func (do *DataOperations) GetMessageQueueLength() (int64, error) {
+	return do.redis.StreamLength(context.Background(), messageQueueStream)
+}
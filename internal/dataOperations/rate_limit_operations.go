@@ -0,0 +1,20 @@
+package dataOperations
+
+import (
+	"context"
+	"time"
+)
+
+const sendRateLimiterKey = "send_rate_limiter"
+
+// TakeSendToken takes a token from the account-wide send rate limiter shared
+// by all instances. When none is left it reports how long to wait.
+func (do *DataOperations) TakeSendToken() (bool, time.Duration, error) {
+	rate := do.config.App.GlobalSendRate
+	burst := do.config.App.GlobalSendBurst
+	if burst < 1 {
+		burst = rate
+	}
+
+	return do.redis.TakeToken(context.Background(), sendRateLimiterKey, float64(rate), burst)
+}
@@ -0,0 +1,62 @@
+package dataOperations
+
+import (
+	"context"
+	"github.com/sinan/auto-message-sender/internal/models"
+	"github.com/sinan/auto-message-sender/pkg/mongodb"
+	"github.com/sinan/auto-message-sender/pkg/redisdb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strconv"
+)
+
+const SchedulesCollection = "scheduler_schedules"
+
+const schedulesVersionKey = "scheduler_schedules_version"
+
+func (do *DataOperations) GetSchedules() ([]models.Schedule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	return mongodb.Query[models.Schedule](do.mongo, SchedulesCollection, bson.M{}, opts)
+}
+
+func (do *DataOperations) GetEnabledSchedules() ([]models.Schedule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	return mongodb.Query[models.Schedule](do.mongo, SchedulesCollection, bson.M{"enabled": true}, opts)
+}
+
+func (do *DataOperations) GetSchedule(name string) (*models.Schedule, error) {
+	return mongodb.GetOneById[models.Schedule](do.mongo, SchedulesCollection, name)
+}
+
+func (do *DataOperations) SaveSchedule(schedule *models.Schedule) error {
+	if err := mongodb.UpsertOne(do.mongo, SchedulesCollection, schedule.Name, schedule); err != nil {
+		return err
+	}
+	return do.bumpSchedulesVersion()
+}
+
+func (do *DataOperations) DeleteSchedule(name string) error {
+	if err := mongodb.DeleteOne(do.mongo, SchedulesCollection, name); err != nil {
+		return err
+	}
+	return do.bumpSchedulesVersion()
+}
+
+// GetSchedulesVersion returns a counter that changes whenever a schedule is
+// saved or deleted, so instances know when to reload their schedules.
+func (do *DataOperations) GetSchedulesVersion() (int64, error) {
+	value, err := do.redis.Get(context.Background(), schedulesVersionKey)
+	if redisdb.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (do *DataOperations) bumpSchedulesVersion() error {
+	_, err := do.redis.Incr(context.Background(), schedulesVersionKey)
+	return err
+}
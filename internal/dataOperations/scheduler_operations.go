@@ -1,14 +1,39 @@
 package dataOperations
 
 import (
+	"context"
+	"errors"
 	"github.com/sinan/auto-message-sender/internal/models"
 	"github.com/sinan/auto-message-sender/pkg/mongodb"
+	"github.com/sinan/auto-message-sender/pkg/redisdb"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 )
 
 const SchedulerLogsCollection = "scheduler_logs"
 
+var ErrSchedulerAlreadyResumed = errors.New("scheduler run was already resumed")
+
+const (
+	schedulerLeaderKey = "scheduler_leader"
+	schedulerConfigKey = "scheduler_config"
+)
+
+// EnsureSchedulerLogIndexes makes resumed_from unique, so that a run is
+// resumed by only one of the instances booting at the same time.
+func (do *DataOperations) EnsureSchedulerLogIndexes() error {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "resumed_from", Value: 1}},
+			Options: options.Index().SetName("resumed_from_unique").SetUnique(true).SetSparse(true),
+		},
+	}
+
+	return mongodb.CreateIndexes(do.mongo, SchedulerLogsCollection, indexModels)
+}
+
 func (do *DataOperations) CreateSchedulerStartLog() (string, error) {
 	log := models.NewSchedulerStartLog()
 	err := mongodb.InsertOne(do.mongo, SchedulerLogsCollection, log)
@@ -20,19 +45,85 @@ func (do *DataOperations) CreateSchedulerStopLog(startID string) error {
 	return mongodb.InsertOne(do.mongo, SchedulerLogsCollection, log)
 }
 
-func (do *DataOperations) IsSchedulerActive() (bool, error) {
+// GetLastSchedulerLog returns the most recent start or stop entry, or nil
+// when the scheduler has never been started.
+func (do *DataOperations) GetLastSchedulerLog() (*models.SchedulerLog, error) {
+	filter := bson.M{
+		"action": bson.M{"$in": bson.A{models.SchedulerActionStart, models.SchedulerActionStop}},
+	}
+
 	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(1)
-	results, err := mongodb.Query[models.SchedulerLog](do.mongo, SchedulerLogsCollection, bson.M{}, opts)
+	results, err := mongodb.Query[models.SchedulerLog](do.mongo, SchedulerLogsCollection, filter, opts)
 
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	if len(results) == 0 {
-		return false, nil
+		return nil, nil
 	}
 
-	return results[0].Action == models.SchedulerActionStart, nil
+	return &results[0], nil
+}
+
+func (do *DataOperations) IsSchedulerActive() (bool, error) {
+	lastLog, err := do.GetLastSchedulerLog()
+	if err != nil {
+		return false, err
+	}
+
+	return lastLog != nil && lastLog.Action == models.SchedulerActionStart, nil
+}
+
+// RestartScheduler closes the previous run with a synthetic stop entry and
+// opens a new run that points back to it, so the audit trail shows the
+// restart. It returns the new start ID, or ErrSchedulerAlreadyResumed when
+// another instance restarted the same run first.
+func (do *DataOperations) RestartScheduler(previousStartID string) (string, error) {
+	startLog := models.NewSchedulerStartLog()
+	startLog.Reason = models.SchedulerLogReasonRestart
+	startLog.ResumedFrom = &previousStartID
+
+	// The start is inserted first because its unique resumed_from decides
+	// which instance writes the pair; the stop still sorts before it.
+	stopLog := models.NewSchedulerStopLog(previousStartID)
+	stopLog.Reason = models.SchedulerLogReasonRestart
+	stopLog.Timestamp = startLog.Timestamp.Add(-time.Millisecond)
+
+	err := mongodb.InsertOne(do.mongo, SchedulerLogsCollection, startLog)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", ErrSchedulerAlreadyResumed
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = mongodb.InsertOne(do.mongo, SchedulerLogsCollection, stopLog)
+	return startLog.ID, err
+}
+
+func (do *DataOperations) CreateSchedulerConfigLog(startID string, config models.SchedulerConfig) error {
+	log := models.NewSchedulerConfigUpdateLog(startID, config)
+	return mongodb.InsertOne(do.mongo, SchedulerLogsCollection, log)
+}
+
+func (do *DataOperations) SaveSchedulerConfig(config models.SchedulerConfig) error {
+	return do.redis.SetJSON(context.Background(), schedulerConfigKey, config, 0)
+}
+
+// GetSchedulerConfig returns nil without an error when the configuration has
+// never been changed at runtime.
+func (do *DataOperations) GetSchedulerConfig() (*models.SchedulerConfig, error) {
+	var config models.SchedulerConfig
+	err := do.redis.GetJSON(context.Background(), schedulerConfigKey, &config)
+	if redisdb.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
 }
 
 func (do *DataOperations) StartScheduler() (string, error) {
@@ -42,3 +133,15 @@ func (do *DataOperations) StartScheduler() (string, error) {
 func (do *DataOperations) StopScheduler(startID string) error {
 	return do.CreateSchedulerStopLog(startID)
 }
+
+func (do *DataOperations) AcquireSchedulerLeadership(instanceID string) (bool, error) {
+	return do.redis.AcquireLock(context.Background(), schedulerLeaderKey, instanceID, do.config.App.LeaderLeaseTTL)
+}
+
+func (do *DataOperations) ReleaseSchedulerLeadership(instanceID string) error {
+	return do.redis.ReleaseLock(context.Background(), schedulerLeaderKey, instanceID)
+}
+
+func (do *DataOperations) GetSchedulerLeader() (string, error) {
+	return do.redis.LockOwner(context.Background(), schedulerLeaderKey)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sinan/auto-message-sender/internal/models"
+	"github.com/sinan/auto-message-sender/internal/validation"
+	"github.com/sirupsen/logrus"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// GetDeadLetterMessages godoc
+// @Summary Get list of dead-lettered messages
+// @Description Retrieve a paginated list of messages that used up all of their retries, most recent first, with their error history
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(10)
+// @Success 200 {object} models.DeadLetterListResponse
+// @Failure 400 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages/dead-letter [get]
+func (h *MessageHandler) GetDeadLetterMessages(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		h.logger.WithError(err).Warn("Invalid page parameter")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid page parameter, must be a positive integer",
+		})
+		return
+	}
+
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	if err != nil || perPage < 1 {
+		h.logger.WithError(err).Warn("Invalid per_page parameter")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid per_page parameter, must be a positive integer",
+		})
+		return
+	}
+
+	if perPage > 100 {
+		h.logger.Warn("per_page parameter too large, limiting to 100")
+		perPage = 100
+	}
+
+	messages, total, err := h.dataOps.GetDeadLetterMessages(page, perPage)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get dead-lettered messages")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve messages",
+		})
+		return
+	}
+
+	response := models.DeadLetterListResponse{
+		Messages:   messages,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: int(math.Ceil(float64(total) / float64(perPage))),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RequeueDeadLetterMessages godoc
+// @Summary Requeue dead-lettered messages
+// @Description Move dead-lettered messages, selected by ID or by filter, back to pending with a fresh retry budget. At most MAX_BATCH_SIZE messages are requeued per request; an empty filter matches every dead-lettered message.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param request body models.RequeueDeadLetterRequest true "Messages to requeue"
+// @Success 200 {object} models.RequeueDeadLetterResponse
+// @Failure 400 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages/dead-letter/requeue [post]
+func (h *MessageHandler) RequeueDeadLetterMessages(c *gin.Context) {
+	var request models.RequeueDeadLetterRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Warn("Invalid requeue request body")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if validationErrors := validateRequeueRequest(request, h.config.App.MaxBatchSize); len(validationErrors) > 0 {
+		h.logger.WithField("validation_errors", validationErrors.Error()).Warn("Requeue validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"details": validationErrors,
+		})
+		return
+	}
+
+	ids, skipped, err := h.dataOps.RequeueDeadLetterMessages(request.IDs, request.Filter, h.config.App.MaxBatchSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to requeue dead-lettered messages")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to requeue messages",
+		})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"requeued": len(ids),
+		"skipped":  len(skipped),
+	}).Info("Dead-lettered messages requeued")
+
+	c.JSON(http.StatusOK, models.RequeueDeadLetterResponse{
+		Requeued:   len(ids),
+		IDs:        ids,
+		SkippedIDs: skipped,
+	})
+}
+
+func validateRequeueRequest(request models.RequeueDeadLetterRequest, maxBatchSize int) validation.ValidationErrors {
+	var validationErrors validation.ValidationErrors
+
+	addError := func(field string, err error) {
+		validationErrors = append(validationErrors, validation.ValidationError{
+			Field:   field,
+			Message: err.Error(),
+		})
+	}
+
+	if len(request.IDs) == 0 && request.Filter == nil {
+		addError("ids", fmt.Errorf("either ids or filter is required"))
+		return validationErrors
+	}
+
+	if len(request.IDs) > 0 && request.Filter != nil {
+		addError("filter", fmt.Errorf("ids and filter cannot be combined"))
+	}
+
+	if len(request.IDs) > maxBatchSize {
+		addError("ids", fmt.Errorf("at most %d ids can be requeued at once", maxBatchSize))
+	}
+
+	for i, id := range request.IDs {
+		if err := validation.ValidateMessageID(id); err != nil {
+			addError(fmt.Sprintf("ids[%d]", i), err)
+		}
+	}
+
+	if request.Filter != nil {
+		if request.Filter.MinPriority != nil {
+			if err := validation.ValidatePriority(*request.Filter.MinPriority); err != nil {
+				addError("filter.min_priority", err)
+			}
+		}
+
+		if request.Filter.MaxPriority != nil {
+			if err := validation.ValidatePriority(*request.Filter.MaxPriority); err != nil {
+				addError("filter.max_priority", err)
+			}
+		}
+
+		if request.Filter.ToPrefix != "" {
+			if err := validation.ValidatePhonePrefix(request.Filter.ToPrefix); err != nil {
+				addError("filter.to_prefix", err)
+			}
+		}
+	}
+
+	return validationErrors
+}
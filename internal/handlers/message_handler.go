@@ -1,15 +1,20 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/sinan/auto-message-sender/internal/config"
 	"github.com/sinan/auto-message-sender/internal/dataOperations"
 	"github.com/sinan/auto-message-sender/internal/models"
+	"github.com/sinan/auto-message-sender/internal/validation"
+	"github.com/sinan/auto-message-sender/pkg/mongodb/filtering"
 	"github.com/sirupsen/logrus"
 	"math"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type MessageHandler struct {
@@ -28,14 +33,716 @@ func NewMessageHandler(dataOps *dataOperations.DataOperations, config *config.Co
 	}
 }
 
+// CreateMessage godoc
+// @Summary Enqueue a new message
+// @Description Validate and store a new pending message to be sent by the scheduler once send_at has passed and before expires_at. Requests repeated with the same Idempotency-Key return the originally created message.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Param request body models.CreateMessageRequest true "Message to enqueue"
+// @Success 201 {object} models.Message
+// @Failure 400 {object} gin.H
+// @Failure 422 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages [post]
+func (h *MessageHandler) CreateMessage(c *gin.Context) {
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if err := validation.ValidateIdempotencyKey(idempotencyKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request models.CreateMessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Warn("Invalid create message request body")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	validationErrors := validateMessage(request.To, request.Content, request.Priority, request.Timezone)
+	validationErrors = append(validationErrors, validateExpiry(request.SendAt, request.ExpiresAt)...)
+	if len(validationErrors) > 0 {
+		h.logger.WithField("validation_errors", validationErrors.Error()).Warn("Message validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"details": validationErrors,
+		})
+		return
+	}
+
+	if idempotencyKey != "" {
+		if existing := h.findIdempotentMessage(idempotencyKey); existing != nil {
+			h.replayMessage(c, idempotencyKey, request, existing)
+			return
+		}
+	}
+
+	message := models.NewMessage(request.To, request.Content, request.Priority, request.SendAt)
+	message.Timezone = request.Timezone
+	message.ExpiresAt = request.ExpiresAt
+	if idempotencyKey != "" {
+		message.IdempotencyKey = &idempotencyKey
+	}
+
+	err := h.dataOps.CreateMessage(message)
+	if errors.Is(err, dataOperations.ErrDuplicateMessage) && idempotencyKey != "" {
+		existing, lookupErr := h.dataOps.GetMessageByIdempotencyKey(idempotencyKey)
+		if lookupErr == nil && existing != nil {
+			h.replayMessage(c, idempotencyKey, request, existing)
+			return
+		}
+		err = errors.Join(err, lookupErr)
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to create message")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create message",
+		})
+		return
+	}
+
+	if idempotencyKey != "" {
+		if err := h.dataOps.CacheIdempotencyKey(idempotencyKey, message.ID); err != nil {
+			h.logger.WithError(err).Warn("Failed to cache idempotency key (non-critical)")
+		}
+	}
+
+	h.enqueueDueMessages([]*models.Message{message})
+
+	h.logger.WithFields(logrus.Fields{
+		"message_id": message.ID,
+		"to":         message.To,
+	}).Info("Message enqueued")
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// enqueueDueMessages pushes messages that are due now to the Redis stream
+// when it is the queue backend. Scheduled messages, and messages that could
+// not be pushed, are found by the scheduler in MongoDB once they are due.
+func (h *MessageHandler) enqueueDueMessages(messages []*models.Message) {
+	if h.config.App.QueueBackend != config.QueueBackendRedisStream {
+		return
+	}
+
+	now := time.Now()
+	messageIDs := make([]string, 0, len(messages))
+	for _, message := range messages {
+		if message.SendAt == nil || !message.SendAt.After(now) {
+			messageIDs = append(messageIDs, message.ID)
+		}
+	}
+
+	if len(messageIDs) == 0 {
+		return
+	}
+
+	if err := h.dataOps.EnqueueMessages(messageIDs); err != nil {
+		h.logger.WithError(err).WithField("message_count", len(messageIDs)).Warn("Failed to push messages to the Redis stream (non-critical)")
+	}
+}
+
+// findIdempotentMessage resolves a previously used key through Redis. A miss
+// falls through to the insert, where the unique index catches anything Redis
+// has already forgotten. That index only covers the messages collection, so
+// dead-lettered messages are looked up here as well.
+func (h *MessageHandler) findIdempotentMessage(idempotencyKey string) *models.Message {
+	record, err := h.dataOps.GetIdempotencyRecord(idempotencyKey)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to read idempotency key from cache")
+		return nil
+	}
+
+	if record == nil {
+		deadLetter, err := h.dataOps.GetDeadLetterMessageByIdempotencyKey(idempotencyKey)
+		if err != nil {
+			h.logger.WithError(err).Warn("Failed to look up dead-lettered message for idempotency key")
+			return nil
+		}
+		if deadLetter == nil {
+			return nil
+		}
+		return &deadLetter.Message
+	}
+
+	message, err := h.dataOps.GetMessageByID(record.MessageID)
+	if err != nil {
+		h.logger.WithError(err).WithField("message_id", record.MessageID).Warn("Failed to load message for idempotency key")
+		return nil
+	}
+
+	if message != nil {
+		return message
+	}
+
+	deadLetter, err := h.dataOps.GetDeadLetterMessage(record.MessageID)
+	if err != nil {
+		h.logger.WithError(err).WithField("message_id", record.MessageID).Warn("Failed to load dead-lettered message for idempotency key")
+		return nil
+	}
+	if deadLetter == nil {
+		return nil
+	}
+
+	return &deadLetter.Message
+}
+
+// replayMessage answers a retried request with the message its key created.
+// A key reused for a different message is rejected instead, because the
+// caller would otherwise believe the new message was enqueued.
+func (h *MessageHandler) replayMessage(c *gin.Context, idempotencyKey string, request models.CreateMessageRequest, message *models.Message) {
+	logger := h.logger.WithFields(logrus.Fields{
+		"message_id":      message.ID,
+		"idempotency_key": idempotencyKey,
+	})
+
+	if message.To != request.To || message.Content != request.Content ||
+		message.Priority != request.Priority || message.Timezone != request.Timezone {
+		logger.Warn("Idempotency key reused for a different message")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Idempotency-Key was already used for a different message",
+		})
+		return
+	}
+
+	logger.Info("Replaying idempotent message creation")
+
+	c.Header("Idempotent-Replayed", "true")
+	c.JSON(http.StatusCreated, message)
+}
+
+// CreateMessageBatch godoc
+// @Summary Enqueue messages in bulk
+// @Description Validate each message and store the valid ones in a single bulk write. Rejected items are reported by their index in the request.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param request body models.BatchCreateMessageRequest true "Messages to enqueue"
+// @Success 200 {object} models.BatchCreateMessageResponse
+// @Failure 400 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages/batch [post]
+func (h *MessageHandler) CreateMessageBatch(c *gin.Context) {
+	var request models.BatchCreateMessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Warn("Invalid batch request body")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if len(request.Messages) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Batch must contain at least one message",
+		})
+		return
+	}
+
+	if len(request.Messages) > h.config.App.MaxBatchSize {
+		h.logger.WithField("batch_size", len(request.Messages)).Warn("Batch too large")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Batch must contain at most %d messages", h.config.App.MaxBatchSize),
+		})
+		return
+	}
+
+	response := models.BatchCreateMessageResponse{
+		Errors: []models.BatchItemError{},
+	}
+
+	messages := make([]*models.Message, 0, len(request.Messages))
+	for index, item := range request.Messages {
+		validationErrors := validateMessage(item.To, item.Content, item.Priority, item.Timezone)
+		validationErrors = append(validationErrors, validateExpiry(item.SendAt, item.ExpiresAt)...)
+		if item.ID != "" {
+			if err := validation.ValidateMessageID(item.ID); err != nil {
+				validationErrors = append(validationErrors, validation.ValidationError{
+					Field:   "id",
+					Message: err.Error(),
+				})
+			}
+		}
+
+		if len(validationErrors) > 0 {
+			response.Errors = append(response.Errors, models.BatchItemError{
+				Index:  index,
+				Errors: validationErrors,
+			})
+			continue
+		}
+
+		message := models.NewMessage(item.To, item.Content, item.Priority, item.SendAt)
+		message.Timezone = item.Timezone
+		message.ExpiresAt = item.ExpiresAt
+		if item.ID != "" {
+			message.ID = item.ID
+		}
+		messages = append(messages, message)
+	}
+
+	response.Rejected = len(response.Errors)
+
+	if len(messages) > 0 {
+		duplicates, err := h.dataOps.CreateMessages(messages)
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to create message batch")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to create messages",
+			})
+			return
+		}
+
+		response.AlreadyEnqueued = duplicates
+		response.Accepted = len(messages) - duplicates
+
+		h.enqueueDueMessages(messages)
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"accepted":         response.Accepted,
+		"already_enqueued": response.AlreadyEnqueued,
+		"rejected":         response.Rejected,
+	}).Info("Message batch processed")
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchMessages godoc
+// @Summary Search messages
+// @Description Retrieve a paginated list of messages matching the filters. filters is a JSON array of {"field", "operation", "value", "valueFrom", "valueTo"} objects; see GET /messages/filters for the allowed fields and operations. Dates use the DD-MM-YYYY format.
+// @Tags messages
+// @Produce json
+// @Param filters query string false "Filters as a JSON array" example([{"field":"status","operation":"equals","value":"failed"}])
+// @Param sortBy query string false "Field to sort by" default(created_at)
+// @Param sortOrder query string false "Sort order" Enums(asc, desc) default(desc)
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Items per page" default(10)
+// @Success 200 {object} models.MessageListResponse
+// @Failure 400 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages [get]
+func (h *MessageHandler) SearchMessages(c *gin.Context) {
+	var request filtering.FilterRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		h.logger.WithError(err).Warn("Invalid message search parameters")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+
+	if request.Page == 0 {
+		request.Page = 1
+	}
+	if request.PageSize == 0 {
+		request.PageSize = 10
+	}
+	if request.SortBy == "" {
+		request.SortBy = "created_at"
+		if request.SortOrder == "" {
+			request.SortOrder = string(filtering.SortDesc)
+		}
+	}
+
+	if request.SortOrder != "" && request.SortOrder != string(filtering.SortAsc) && request.SortOrder != string(filtering.SortDesc) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid sortOrder parameter '%s', expected 'asc' or 'desc'", request.SortOrder),
+		})
+		return
+	}
+
+	metadata := models.MessageFilterMetadata
+	if !metadata.IsSortable(request.SortBy) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid sortBy parameter, field '%s' is not sortable", request.SortBy),
+		})
+		return
+	}
+
+	builder := filtering.MongoFilterBuilder{
+		Request:  &request,
+		Metadata: &metadata,
+	}
+
+	filter, err := builder.BuildFilter()
+	if err != nil {
+		h.logger.WithError(err).Warn("Invalid message search filters")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	messages, total, err := h.dataOps.SearchMessages(filter, builder.BuildFindOptions())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to search messages")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve messages",
+		})
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(request.PageSize)))
+
+	response := models.MessageListResponse{
+		Messages:   messages,
+		Total:      &total,
+		Page:       request.Page,
+		PerPage:    request.PageSize,
+		TotalPages: &totalPages,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMessageFilters godoc
+// @Summary List message search filters
+// @Description Describe the fields, operations and sort fields accepted by GET /messages
+// @Tags messages
+// @Produce json
+// @Success 200 {object} filtering.FilterMetadata
+// @Router /messages/filters [get]
+func (h *MessageHandler) GetMessageFilters(c *gin.Context) {
+	c.JSON(http.StatusOK, models.MessageFilterMetadata)
+}
+
+// GetMessage godoc
+// @Summary Get a message
+// @Description Retrieve a message, including dead-lettered ones, with its delivery attempts and error history
+// @Tags messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {object} models.Message
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages/{id} [get]
+func (h *MessageHandler) GetMessage(c *gin.Context) {
+	messageID := c.Param("id")
+	if err := validation.ValidateMessageID(messageID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	message, err := h.dataOps.GetMessageByID(messageID)
+	if err != nil {
+		h.logger.WithError(err).WithField("message_id", messageID).Error("Failed to get message")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve message",
+		})
+		return
+	}
+
+	if message != nil {
+		c.JSON(http.StatusOK, message)
+		return
+	}
+
+	deadLetter, err := h.dataOps.GetDeadLetterMessage(messageID)
+	if err != nil {
+		h.logger.WithError(err).WithField("message_id", messageID).Error("Failed to get dead-lettered message")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve message",
+		})
+		return
+	}
+
+	if deadLetter == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Message not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetter)
+}
+
+// GetMessageByProviderID godoc
+// @Summary Get a message by the provider's message ID
+// @Description Retrieve a sent message by the message ID the webhook provider returned. Recently sent messages are resolved through Redis before falling back to MongoDB.
+// @Tags messages
+// @Produce json
+// @Param messageId path string true "Provider message ID"
+// @Success 200 {object} models.Message
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages/provider/{messageId} [get]
+func (h *MessageHandler) GetMessageByProviderID(c *gin.Context) {
+	providerMessageID := c.Param("messageId")
+	if err := validation.ValidateProviderMessageID(providerMessageID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	logger := h.logger.WithField("provider_message_id", providerMessageID)
+
+	if message := h.findCachedMessage(logger, providerMessageID); message != nil {
+		c.JSON(http.StatusOK, message)
+		return
+	}
+
+	message, err := h.dataOps.GetMessageByProviderID(providerMessageID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get message by provider message ID")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve message",
+		})
+		return
+	}
+
+	if message == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Message not found",
+		})
+		return
+	}
+
+	sentAt := message.CreatedAt
+	if message.SentAt != nil {
+		sentAt = *message.SentAt
+	}
+	if err := h.dataOps.CacheMessage(message.ID, providerMessageID, sentAt); err != nil {
+		logger.WithError(err).Warn("Failed to cache message (non-critical)")
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// findCachedMessage resolves the provider's message ID through Redis. Entries
+// written before the internal ID was cached fall through to MongoDB.
+func (h *MessageHandler) findCachedMessage(logger *logrus.Entry, providerMessageID string) *models.Message {
+	cached, err := h.dataOps.GetCachedMessage(providerMessageID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to read message from cache")
+		return nil
+	}
+
+	if cached == nil || cached.ID == "" {
+		return nil
+	}
+
+	message, err := h.dataOps.GetMessageByID(cached.ID)
+	if err != nil {
+		logger.WithError(err).WithField("message_id", cached.ID).Warn("Failed to load cached message")
+		return nil
+	}
+
+	return message
+}
+
+// CancelMessage godoc
+// @Summary Cancel a message
+// @Description Cancel a message that has not been picked up for sending yet
+// @Tags messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {object} models.Message
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 409 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages/{id} [delete]
+func (h *MessageHandler) CancelMessage(c *gin.Context) {
+	messageID := c.Param("id")
+	if err := validation.ValidateMessageID(messageID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	message, err := h.dataOps.CancelMessage(messageID)
+	if err != nil {
+		h.respondPendingUpdateError(c, messageID, err, "cancel")
+		return
+	}
+
+	h.logger.WithField("message_id", messageID).Info("Message cancelled")
+
+	c.JSON(http.StatusOK, message)
+}
+
+// UpdateMessage godoc
+// @Summary Edit a message
+// @Description Change the recipient, content or send time of a message that has not been picked up for sending yet. The send time must stay before the message's expires_at.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param request body models.UpdateMessageRequest true "Fields to change"
+// @Success 200 {object} models.Message
+// @Failure 400 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 409 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /messages/{id} [patch]
+func (h *MessageHandler) UpdateMessage(c *gin.Context) {
+	messageID := c.Param("id")
+	if err := validation.ValidateMessageID(messageID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var request models.UpdateMessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Warn("Invalid update message request body")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if request.To == nil && request.Content == nil && request.SendAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "At least one of to, content or send_at is required",
+		})
+		return
+	}
+
+	existing, err := h.dataOps.GetMessageByID(messageID)
+	if err != nil {
+		h.logger.WithError(err).WithField("message_id", messageID).Error("Failed to get message")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update message",
+		})
+		return
+	}
+
+	if existing == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Message not found",
+		})
+		return
+	}
+
+	if validationErrors := validateMessageUpdate(*existing, request); len(validationErrors) > 0 {
+		h.logger.WithField("validation_errors", validationErrors.Error()).Warn("Message validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"details": validationErrors,
+		})
+		return
+	}
+
+	message, err := h.dataOps.EditMessage(messageID, request)
+	if err != nil {
+		h.respondPendingUpdateError(c, messageID, err, "update")
+		return
+	}
+
+	h.logger.WithField("message_id", messageID).Info("Message updated")
+
+	c.JSON(http.StatusOK, message)
+}
+
+func (h *MessageHandler) respondPendingUpdateError(c *gin.Context, messageID string, err error, action string) {
+	switch {
+	case errors.Is(err, dataOperations.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Message not found",
+		})
+	case errors.Is(err, dataOperations.ErrMessageNotPending):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Message is no longer pending",
+		})
+	case errors.Is(err, dataOperations.ErrSendAtAfterExpiry):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Validation failed",
+			"details": validation.ValidationErrors{{
+				Field:   "send_at",
+				Message: "send_at must be before expires_at",
+			}},
+		})
+	default:
+		h.logger.WithError(err).WithField("message_id", messageID).Errorf("Failed to %s message", action)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to %s message", action),
+		})
+	}
+}
+
+// validateMessageUpdate runs the checks of a new message against the message
+// as the update would leave it, keeping its stored priority and timezone.
+func validateMessageUpdate(message models.Message, request models.UpdateMessageRequest) validation.ValidationErrors {
+	to, content := message.To, message.Content
+	if request.To != nil {
+		to = *request.To
+	}
+	if request.Content != nil {
+		content = *request.Content
+	}
+
+	return validateMessage(to, content, message.Priority, message.Timezone)
+}
+
+func validateMessage(to, content string, priority int, timezone string) validation.ValidationErrors {
+	validationErrors := validation.ValidateWebhookRequest(to, content)
+
+	if err := validation.ValidatePriority(priority); err != nil {
+		validationErrors = append(validationErrors, validation.ValidationError{
+			Field:   "priority",
+			Message: err.Error(),
+		})
+	}
+
+	if timezone != "" {
+		if err := validation.ValidateTimezone(timezone); err != nil {
+			validationErrors = append(validationErrors, validation.ValidationError{
+				Field:   "timezone",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	return validationErrors
+}
+
+// validateExpiry requires expires_at to lie in the future and after send_at.
+func validateExpiry(sendAt, expiresAt *time.Time) validation.ValidationErrors {
+	var validationErrors validation.ValidationErrors
+
+	if expiresAt == nil {
+		return validationErrors
+	}
+
+	if !expiresAt.After(time.Now()) {
+		validationErrors = append(validationErrors, validation.ValidationError{
+			Field:   "expires_at",
+			Message: "expires_at must be in the future",
+		})
+	} else if sendAt != nil && !expiresAt.After(*sendAt) {
+		validationErrors = append(validationErrors, validation.ValidationError{
+			Field:   "expires_at",
+			Message: "expires_at must be after send_at",
+		})
+	}
+
+	return validationErrors
+}
+
 // GetSentMessages godoc
 // @Summary Get list of sent messages
-// @Description Retrieve a paginated list of sent messages
+// @Description Retrieve a list of sent messages, most recent first. Follow next_cursor to page through the list without skipping or repeating messages; page is only used when no cursor is given. The total count is included when include_total is true, which is the default without a cursor.
 // @Tags messages
 // @Accept json
 // @Produce json
+// @Param cursor query string false "next_cursor of the previous page"
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(10)
+// @Param include_total query bool false "Count all sent messages"
 // @Success 200 {object} models.MessageListResponse
 // @Failure 400 {object} gin.H
 // @Failure 500 {object} gin.H
@@ -64,7 +771,33 @@ func (h *MessageHandler) GetSentMessages(c *gin.Context) {
 		perPage = 100
 	}
 
-	messages, total, err := h.dataOps.GetSentMessages(page, perPage)
+	var cursor *models.SentMessagesCursor
+	if encoded := c.Query("cursor"); encoded != "" {
+		cursor, err = models.DecodeSentMessagesCursor(encoded)
+		if err != nil {
+			h.logger.WithError(err).Warn("Invalid cursor parameter")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cursor parameter",
+			})
+			return
+		}
+	}
+
+	includeTotal, err := strconv.ParseBool(c.DefaultQuery("include_total", strconv.FormatBool(cursor == nil)))
+	if err != nil {
+		h.logger.WithError(err).Warn("Invalid include_total parameter")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid include_total parameter, must be a boolean",
+		})
+		return
+	}
+
+	var messages []models.Message
+	if cursor != nil {
+		messages, err = h.dataOps.GetSentMessagesAfter(*cursor, perPage)
+	} else {
+		messages, err = h.dataOps.GetSentMessages(page, perPage)
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get sent messages")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -73,20 +806,42 @@ func (h *MessageHandler) GetSentMessages(c *gin.Context) {
 		return
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
-
 	response := models.MessageListResponse{
-		Messages:   messages,
-		Total:      total,
-		Page:       page,
-		PerPage:    perPage,
-		TotalPages: totalPages,
+		Messages: messages,
+		PerPage:  perPage,
+	}
+
+	if cursor == nil {
+		response.Page = page
+	}
+
+	if len(messages) == perPage {
+		last := messages[len(messages)-1]
+		if last.SentAt != nil {
+			response.NextCursor = models.SentMessagesCursor{SentAt: *last.SentAt, ID: last.ID}.Encode()
+		}
+	}
+
+	if includeTotal {
+		total, err := h.dataOps.CountSentMessages()
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to count sent messages")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve messages",
+			})
+			return
+		}
+
+		totalPages := int(math.Ceil(float64(total) / float64(perPage)))
+		response.Total = &total
+		response.TotalPages = &totalPages
 	}
 
 	h.logger.WithFields(logrus.Fields{
-		"total_messages": total,
-		"page":           page,
-		"per_page":       perPage,
+		"message_count": len(messages),
+		"page":          response.Page,
+		"per_page":      perPage,
+		"cursor":        cursor != nil,
 	}).Info("Retrieved sent messages")
 
 	c.JSON(http.StatusOK, response)
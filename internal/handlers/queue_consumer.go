@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"github.com/sinan/auto-message-sender/internal/config"
+	"github.com/sinan/auto-message-sender/pkg/redisdb"
+	"sync"
+	"time"
+)
+
+// sendBudget is what is left of MessagesPerInterval in the current interval
+// of ticker mode. Every tick starts a new generation, so that a reservation
+// made in an earlier interval is not given back to the current one.
+type sendBudget struct {
+	mu         sync.Mutex
+	remaining  int
+	generation int
+}
+
+func (b *sendBudget) reset(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remaining = n
+	b.generation++
+}
+
+// take reserves up to n sends and returns how many it got along with the
+// generation to give unused ones back to.
+func (b *sendBudget) take(n int) (int, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	granted := max(min(n, b.remaining), 0)
+	b.remaining -= granted
+	return granted, b.generation
+}
+
+func (b *sendBudget) giveBack(n, generation int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > 0 && generation == b.generation {
+		b.remaining += n
+	}
+}
+
+// consumeQueue feeds the worker pool from the Redis stream that new messages
+// are pushed to, so that they are picked up within moments of being created.
+// Each entry is only a pointer: the message is claimed in MongoDB as usual and
+// the entry is acknowledged once the claim was attempted. Messages that are
+// not due yet, deferred or retried are left to the regular MongoDB path. In
+// ticker mode the consumer only uses what the last tick left of
+// MessagesPerInterval.
+func (h *SchedulerHandler) consumeQueue(stop <-chan struct{}) {
+	defer h.loop.Done()
+
+	block := h.config.App.StreamPollInterval
+	if block <= 0 {
+		block = time.Second
+	}
+	consumer := h.config.App.InstanceID
+
+	h.logger.WithField("consumer", consumer).Info("Redis stream consumer started")
+
+	groupReady := false
+	var lastReclaim time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		if !h.leading() || h.hasSchedules.Load() {
+			if !sleepUntilStopped(stop, block) {
+				return
+			}
+			continue
+		}
+
+		if !groupReady {
+			if err := h.dataOps.EnsureMessageQueueGroup(); err != nil {
+				h.logger.WithError(err).Error("Failed to create Redis stream consumer group")
+				if !sleepUntilStopped(stop, block) {
+					return
+				}
+				continue
+			}
+			groupReady = true
+		}
+
+		free := h.pool.free()
+		if free == 0 {
+			select {
+			case <-stop:
+				return
+			case <-h.pool.ready:
+			case <-time.After(block):
+			}
+			continue
+		}
+
+		limit, generation := free, 0
+		if h.mode == config.SchedulerModeTicker {
+			if limit, generation = h.budget.take(free); limit == 0 {
+				if !sleepUntilStopped(stop, block) {
+					return
+				}
+				continue
+			}
+		}
+
+		var entries []redisdb.StreamEntry
+		var err error
+		if time.Since(lastReclaim) >= h.config.App.LeaderLeaseTTL {
+			lastReclaim = time.Now()
+			entries, err = h.dataOps.ReclaimMessageQueue(consumer, h.config.App.LeaderLeaseTTL, limit)
+		} else {
+			entries, err = h.dataOps.ReadMessageQueue(consumer, limit, block)
+		}
+
+		claimed := 0
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to read from Redis stream")
+		} else {
+			claimed = h.dispatchQueueEntries(entries)
+		}
+
+		if h.mode == config.SchedulerModeTicker {
+			h.budget.giveBack(limit-claimed, generation)
+		}
+
+		if err != nil && !sleepUntilStopped(stop, block) {
+			return
+		}
+	}
+}
+
+// dispatchQueueEntries returns how many of the entries' messages were
+// claimed and queued for the worker pool.
+func (h *SchedulerHandler) dispatchQueueEntries(entries []redisdb.StreamEntry) int {
+	if len(entries) == 0 {
+		return 0
+	}
+
+	handled := make([]string, 0, len(entries))
+	claimed := 0
+	for _, entry := range entries {
+		messageID, _ := entry.Values["message_id"].(string)
+		if messageID != "" {
+			message, err := h.dataOps.ClaimMessage(h.config.App.InstanceID, messageID)
+			if err != nil {
+				// Left unacknowledged, so that it is reclaimed later.
+				h.logger.WithError(err).WithField("message_id", messageID).Error("Failed to claim queued message")
+				continue
+			}
+
+			if message != nil {
+				h.pool.submit(*message)
+				claimed++
+			}
+		}
+
+		handled = append(handled, entry.ID)
+	}
+
+	if err := h.dataOps.AckMessageQueue(handled...); err != nil {
+		h.logger.WithError(err).Warn("Failed to acknowledge Redis stream entries")
+	}
+
+	if claimed > 0 {
+		h.logger.WithField("message_count", claimed).Info("Processing queued messages")
+	}
+
+	return claimed
+}
+
+// sleepUntilStopped waits for d and reports false when stop was closed first.
+func sleepUntilStopped(stop <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-stop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
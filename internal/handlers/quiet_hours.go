@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sinan/auto-message-sender/internal/models"
+	"github.com/sinan/auto-message-sender/internal/validation"
+	"time"
+)
+
+var errUnknownTimezone = errors.New("recipient time zone is unknown")
+
+// unknownTimezoneDelay is how long a message waits before its recipient's time
+// zone is looked up again when it could not be determined, rather than risk
+// sending it at night.
+const unknownTimezoneDelay = time.Hour
+
+// quietHours is a daily window, in the recipient's local time, during which
+// non-urgent messages are not delivered. The window may span midnight.
+type quietHours struct {
+	start, end int // minutes since midnight
+}
+
+// parseQuietHours returns nil when either bound is empty, which disables
+// quiet hours.
+func parseQuietHours(start, end string) (*quietHours, error) {
+	if start == "" || end == "" {
+		return nil, nil
+	}
+
+	startMinutes, err := parseClock(start)
+	if err != nil {
+		return nil, err
+	}
+
+	endMinutes, err := parseClock(end)
+	if err != nil {
+		return nil, err
+	}
+
+	if startMinutes == endMinutes {
+		return nil, fmt.Errorf("quiet hours start and end must differ")
+	}
+
+	return &quietHours{start: startMinutes, end: endMinutes}, nil
+}
+
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// deferUntil reports whether now falls into the window in location and, if
+// so, when the window ends.
+func (q *quietHours) deferUntil(now time.Time, location *time.Location) (time.Time, bool) {
+	local := now.In(location)
+	minutes := local.Hour()*60 + local.Minute()
+
+	var inWindow bool
+	if q.start < q.end {
+		inWindow = minutes >= q.start && minutes < q.end
+	} else {
+		inWindow = minutes >= q.start || minutes < q.end
+	}
+
+	if !inWindow {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), q.end/60, q.end%60, 0, 0, location)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+
+	return until, true
+}
+
+// deferUntilAll reports whether now falls into the window in any of the
+// locations and, if so, when it has ended in all of them. Gives up after a
+// few rounds when the windows chain into each other around the clock.
+func (q *quietHours) deferUntilAll(now time.Time, locations []*time.Location) (time.Time, bool) {
+	until := now
+	deferred := false
+
+	for round := 0; round < 4; round++ {
+		latest, inWindow := until, false
+		for _, location := range locations {
+			if end, ok := q.deferUntil(until, location); ok {
+				inWindow = true
+				if end.After(latest) {
+					latest = end
+				}
+			}
+		}
+
+		if !inWindow {
+			break
+		}
+		until, deferred = latest, true
+	}
+
+	return until, deferred
+}
+
+// recipientLocations prefers the time zone set on the message and falls
+// back to the ones derived from the recipient's country code. It fails only
+// when the number has no country code or the time zone data is missing.
+func recipientLocations(message models.Message) ([]*time.Location, bool) {
+	timezones := []string{message.Timezone}
+	if message.Timezone == "" {
+		var ok bool
+		if timezones, ok = validation.TimezonesForPhoneNumber(message.To); !ok {
+			return nil, false
+		}
+	}
+
+	locations := make([]*time.Location, 0, len(timezones))
+	for _, timezone := range timezones {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, false
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, true
+}
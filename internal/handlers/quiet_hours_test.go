@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"github.com/sinan/auto-message-sender/internal/models"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestDeferUntil(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul") // UTC+3, no DST
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		start, end   string
+		now          time.Time
+		wantDeferred bool
+		want         time.Time
+	}{
+		{
+			name:         "before midnight",
+			start:        "21:00",
+			end:          "08:00",
+			now:          time.Date(2026, 1, 15, 22, 30, 0, 0, istanbul),
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 16, 8, 0, 0, 0, istanbul),
+		},
+		{
+			name:         "after midnight",
+			start:        "21:00",
+			end:          "08:00",
+			now:          time.Date(2026, 1, 16, 3, 0, 0, 0, istanbul),
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 16, 8, 0, 0, 0, istanbul),
+		},
+		{
+			name:         "window starts",
+			start:        "21:00",
+			end:          "08:00",
+			now:          time.Date(2026, 1, 15, 21, 0, 0, 0, istanbul),
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 16, 8, 0, 0, 0, istanbul),
+		},
+		{
+			name:  "window ends",
+			start: "21:00",
+			end:   "08:00",
+			now:   time.Date(2026, 1, 16, 8, 0, 0, 0, istanbul),
+		},
+		{
+			name:  "daytime",
+			start: "21:00",
+			end:   "08:00",
+			now:   time.Date(2026, 1, 15, 12, 0, 0, 0, istanbul),
+		},
+		{
+			name:         "same day window",
+			start:        "12:00",
+			end:          "14:00",
+			now:          time.Date(2026, 1, 15, 13, 0, 0, 0, istanbul),
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 15, 14, 0, 0, 0, istanbul),
+		},
+		{
+			name:  "after same day window",
+			start: "12:00",
+			end:   "14:00",
+			now:   time.Date(2026, 1, 15, 23, 0, 0, 0, istanbul),
+		},
+		{
+			name:         "converts to the recipient's clock",
+			start:        "21:00",
+			end:          "08:00",
+			now:          time.Date(2026, 1, 15, 19, 0, 0, 0, time.UTC), // 22:00 in Istanbul
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 16, 5, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := parseQuietHours(tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("parseQuietHours(%q, %q): %v", tt.start, tt.end, err)
+			}
+
+			got, deferred := window.deferUntil(tt.now, istanbul)
+			if deferred != tt.wantDeferred {
+				t.Fatalf("deferUntil(%v) deferred = %v, want %v", tt.now, deferred, tt.wantDeferred)
+			}
+			if deferred && !got.Equal(tt.want) {
+				t.Fatalf("deferUntil(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeferUntilAll(t *testing.T) {
+	window, err := parseQuietHours("21:00", "08:00")
+	if err != nil {
+		t.Fatalf("parseQuietHours: %v", err)
+	}
+
+	// Every three hours around the clock, so that it is always night in one
+	// of them and only the round cap ends the search.
+	aroundTheClock := make([]*time.Location, 0, 8)
+	for offset := 0; offset < 24; offset += 3 {
+		aroundTheClock = append(aroundTheClock, time.FixedZone("", offset*3600))
+	}
+
+	tests := []struct {
+		name         string
+		to           string
+		locations    []*time.Location
+		now          time.Time
+		wantDeferred bool
+		want         time.Time
+	}{
+		{
+			name:         "+1 waits for the west coast",
+			to:           "+12125550100",
+			now:          time.Date(2026, 1, 15, 4, 0, 0, 0, time.UTC), // 23:00 New York, 20:00 Los Angeles
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 15, 16, 0, 0, 0, time.UTC), // 08:00 Los Angeles
+		},
+		{
+			name: "+1 daytime everywhere",
+			to:   "+12125550100",
+			now:  time.Date(2026, 1, 15, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "+7 waits for kaliningrad",
+			to:           "+74951234567",
+			now:          time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC), // 00:00 Kamchatka, 14:00 Kaliningrad
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 16, 6, 0, 0, 0, time.UTC), // 08:00 Kaliningrad
+		},
+		{
+			name:         "unknown country code uses its world zone",
+			to:           "+212612345678",
+			now:          time.Date(2026, 1, 15, 3, 0, 0, 0, time.UTC),
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC), // 08:00 Cape Verde
+		},
+		{
+			name:         "gives up after four rounds",
+			locations:    aroundTheClock,
+			now:          time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+			wantDeferred: true,
+			want:         time.Date(2026, 1, 16, 14, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locations := tt.locations
+			if locations == nil {
+				var ok bool
+				locations, ok = recipientLocations(models.Message{To: tt.to})
+				if !ok {
+					t.Fatalf("recipientLocations(%q) found no time zone", tt.to)
+				}
+			}
+
+			got, deferred := window.deferUntilAll(tt.now, locations)
+			if deferred != tt.wantDeferred {
+				t.Fatalf("deferUntilAll(%v) deferred = %v, want %v", tt.now, deferred, tt.wantDeferred)
+			}
+			if deferred && !got.Equal(tt.want) {
+				t.Fatalf("deferUntilAll(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sinan/auto-message-sender/internal/models"
+	"github.com/sinan/auto-message-sender/internal/validation"
+	"github.com/sinan/auto-message-sender/pkg/cron"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// scheduleRunners holds the goroutines that fire the named cron schedules.
+// While at least one schedule is enabled, they replace the fixed ticker.
+type scheduleRunners struct {
+	mu      sync.Mutex
+	active  bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	loaded  []models.Schedule
+	version int64
+}
+
+// GetSchedules godoc
+// @Summary List named schedules
+// @Description List the cron schedules the scheduler runs instead of the fixed interval
+// @Tags scheduler
+// @Produce json
+// @Success 200 {array} models.Schedule
+// @Failure 500 {object} gin.H
+// @Router /scheduler/schedules [get]
+func (h *SchedulerHandler) GetSchedules(c *gin.Context) {
+	schedules, err := h.dataOps.GetSchedules()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get schedules")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve schedules",
+		})
+		return
+	}
+
+	now := time.Now()
+	for i := range schedules {
+		schedules[i].NextRunAt = nextRun(schedules[i], now)
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// SaveSchedule godoc
+// @Summary Create or replace a named schedule
+// @Description Define a cron schedule, evaluated in an IANA time zone, with its own batch size and message filter. The cron expression has five fields, or six with leading seconds (e.g. "*/30 * 8-19 * * MON-FRI").
+// @Tags scheduler
+// @Accept json
+// @Produce json
+// @Param name path string true "Schedule name"
+// @Param request body models.ScheduleRequest true "Schedule definition"
+// @Success 200 {object} models.Schedule
+// @Failure 400 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /scheduler/schedules/{name} [put]
+func (h *SchedulerHandler) SaveSchedule(c *gin.Context) {
+	name := c.Param("name")
+
+	var request models.ScheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Warn("Invalid schedule request body")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if request.Timezone == "" {
+		request.Timezone = "UTC"
+	}
+
+	if validationErrors := validateSchedule(name, request); len(validationErrors) > 0 {
+		h.logger.WithField("validation_errors", validationErrors.Error()).Warn("Schedule validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"details": validationErrors,
+		})
+		return
+	}
+
+	existing, err := h.dataOps.GetSchedule(name)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get schedule")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save schedule",
+		})
+		return
+	}
+
+	now := time.Now()
+	schedule := &models.Schedule{
+		Name:      name,
+		Cron:      request.Cron,
+		Timezone:  request.Timezone,
+		BatchSize: request.BatchSize,
+		Filter:    request.Filter,
+		Enabled:   request.Enabled == nil || *request.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if existing != nil {
+		schedule.CreatedAt = existing.CreatedAt
+	}
+
+	if err := h.dataOps.SaveSchedule(schedule); err != nil {
+		h.logger.WithError(err).Error("Failed to save schedule")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save schedule",
+		})
+		return
+	}
+
+	h.reloadSchedules()
+
+	h.logger.WithFields(logrus.Fields{
+		"schedule": schedule.Name,
+		"cron":     schedule.Cron,
+		"timezone": schedule.Timezone,
+	}).Info("Schedule saved")
+
+	schedule.NextRunAt = nextRun(*schedule, now)
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule godoc
+// @Summary Delete a named schedule
+// @Description Delete a cron schedule. When no enabled schedule is left, the scheduler falls back to the fixed interval.
+// @Tags scheduler
+// @Produce json
+// @Param name path string true "Schedule name"
+// @Success 200 {object} gin.H
+// @Failure 404 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /scheduler/schedules/{name} [delete]
+func (h *SchedulerHandler) DeleteSchedule(c *gin.Context) {
+	name := c.Param("name")
+
+	existing, err := h.dataOps.GetSchedule(name)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get schedule")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete schedule",
+		})
+		return
+	}
+
+	if existing == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Schedule not found",
+		})
+		return
+	}
+
+	if err := h.dataOps.DeleteSchedule(name); err != nil {
+		h.logger.WithError(err).Error("Failed to delete schedule")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete schedule",
+		})
+		return
+	}
+
+	h.reloadSchedules()
+
+	h.logger.WithField("schedule", name).Info("Schedule deleted")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Schedule deleted successfully",
+	})
+}
+
+func validateSchedule(name string, request models.ScheduleRequest) validation.ValidationErrors {
+	var validationErrors validation.ValidationErrors
+
+	addError := func(field string, err error) {
+		validationErrors = append(validationErrors, validation.ValidationError{
+			Field:   field,
+			Message: err.Error(),
+		})
+	}
+
+	if err := validation.ValidateScheduleName(name); err != nil {
+		addError("name", err)
+	}
+
+	if _, err := cron.Parse(request.Cron); err != nil {
+		addError("cron", err)
+	}
+
+	if err := validation.ValidateTimezone(request.Timezone); err != nil {
+		addError("timezone", err)
+	}
+
+	if request.BatchSize < 1 {
+		addError("batch_size", fmt.Errorf("batch size must be a positive integer"))
+	}
+
+	if request.Filter.MinPriority != nil {
+		if err := validation.ValidatePriority(*request.Filter.MinPriority); err != nil {
+			addError("filter.min_priority", err)
+		}
+	}
+
+	if request.Filter.MaxPriority != nil {
+		if err := validation.ValidatePriority(*request.Filter.MaxPriority); err != nil {
+			addError("filter.max_priority", err)
+		}
+	}
+
+	if request.Filter.ToPrefix != "" {
+		if err := validation.ValidatePhonePrefix(request.Filter.ToPrefix); err != nil {
+			addError("filter.to_prefix", err)
+		}
+	}
+
+	return validationErrors
+}
+
+func nextRun(schedule models.Schedule, after time.Time) *time.Time {
+	expr, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		return nil
+	}
+
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil
+	}
+
+	next := expr.Next(after.In(location))
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// syncSchedules reloads the schedules when they were changed, possibly
+// through another instance.
+func (h *SchedulerHandler) syncSchedules() {
+	version, err := h.dataOps.GetSchedulesVersion()
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to read schedules version")
+		return
+	}
+
+	h.schedules.mu.Lock()
+	current := h.schedules.version
+	h.schedules.mu.Unlock()
+
+	if version != current {
+		h.reloadSchedules()
+	}
+}
+
+func (h *SchedulerHandler) startSchedules() {
+	h.schedules.mu.Lock()
+	h.schedules.active = true
+	h.schedules.mu.Unlock()
+
+	h.reloadSchedules()
+}
+
+func (h *SchedulerHandler) stopSchedules() {
+	h.schedules.mu.Lock()
+	defer h.schedules.mu.Unlock()
+
+	h.schedules.active = false
+	h.stopScheduleRunners()
+}
+
+// reloadSchedules replaces the running schedule goroutines with the enabled
+// schedules from the database. It does nothing while the scheduler is
+// stopped. If the schedules cannot be loaded the current ones keep running.
+func (h *SchedulerHandler) reloadSchedules() {
+	h.schedules.mu.Lock()
+	defer h.schedules.mu.Unlock()
+
+	if !h.schedules.active {
+		return
+	}
+
+	version, err := h.dataOps.GetSchedulesVersion()
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to read schedules version")
+	}
+
+	schedules, err := h.dataOps.GetEnabledSchedules()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to load schedules")
+		return
+	}
+
+	h.stopScheduleRunners()
+
+	h.schedules.stop = make(chan struct{})
+	h.schedules.loaded = nil
+
+	var names []string
+	h.schedules.version = version
+
+	for _, schedule := range schedules {
+		expr, err := cron.Parse(schedule.Cron)
+		if err != nil {
+			h.logger.WithError(err).WithField("schedule", schedule.Name).Error("Skipping schedule with invalid cron expression")
+			continue
+		}
+
+		location, err := time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			h.logger.WithError(err).WithField("schedule", schedule.Name).Error("Skipping schedule with invalid time zone")
+			continue
+		}
+
+		h.schedules.loaded = append(h.schedules.loaded, schedule)
+		names = append(names, schedule.Name)
+		h.schedules.wg.Add(1)
+		go h.runSchedule(schedule, expr, location, h.schedules.stop)
+	}
+
+	h.hasSchedules.Store(len(h.schedules.loaded) > 0)
+
+	h.logger.WithField("schedules", names).Info("Schedules loaded")
+}
+
+// stopScheduleRunners must be called with schedules.mu held.
+func (h *SchedulerHandler) stopScheduleRunners() {
+	if h.schedules.stop != nil {
+		close(h.schedules.stop)
+		h.schedules.stop = nil
+	}
+
+	h.schedules.wg.Wait()
+	h.schedules.loaded = nil
+	h.hasSchedules.Store(false)
+}
+
+func (h *SchedulerHandler) runSchedule(schedule models.Schedule, expr *cron.Schedule, location *time.Location, stop <-chan struct{}) {
+	defer h.schedules.wg.Done()
+
+	logger := h.logger.WithField("schedule", schedule.Name)
+
+	for {
+		next := expr.Next(time.Now().In(location))
+		if next.IsZero() {
+			logger.Warn("Schedule never fires, stopping it")
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !h.leading() {
+			logger.Debug("Not the scheduler leader, skipping schedule run")
+			continue
+		}
+
+		logger.Debug("Running schedule")
+		h.processMessages(schedule.BatchSize, schedule.Filter)
+	}
+}
+
+func (h *SchedulerHandler) scheduleNames() []string {
+	h.schedules.mu.Lock()
+	defer h.schedules.mu.Unlock()
+
+	names := make([]string, len(h.schedules.loaded))
+	for i, schedule := range h.schedules.loaded {
+		names[i] = schedule.Name
+	}
+	return names
+}
+
+// nextScheduleRun returns the earliest next run of the running schedules, or
+// nil when none of them fires again.
+func (h *SchedulerHandler) nextScheduleRun(after time.Time) *time.Time {
+	h.schedules.mu.Lock()
+	defer h.schedules.mu.Unlock()
+
+	var earliest *time.Time
+	for _, schedule := range h.schedules.loaded {
+		if next := nextRun(schedule, after); next != nil && (earliest == nil || next.Before(*earliest)) {
+			earliest = next
+		}
+	}
+	return earliest
+}
@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/sinan/auto-message-sender/internal/config"
 	"github.com/sinan/auto-message-sender/internal/dataOperations"
 	"github.com/sinan/auto-message-sender/internal/models"
 	"github.com/sirupsen/logrus"
+	"math/bits"
+	"math/rand"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,21 +21,79 @@ type SchedulerHandler struct {
 	config         *config.Config
 	logger         *logrus.Logger
 	webhookHandler *WebhookHandler
+	quietHours     *quietHours
+	mode           string
+	queueBackend   string
 	ticker         *time.Ticker
 	stopChan       chan struct{}
+	followStop     chan struct{}
+	following      sync.WaitGroup
 	isRunning      bool
-	activeJobs     sync.WaitGroup
+	isLeader       atomic.Bool
+	renewedAt      atomic.Int64
+	hasSchedules   atomic.Bool
+	schedules      scheduleRunners
+	pool           *workerPool
+	budget         sendBudget
+	loop           sync.WaitGroup
+	interval       atomic.Int64
+	batchSize      atomic.Int64
 	currentStartID string
+	startedAt      time.Time
+	lastTickAt     atomic.Int64
+	nextTickAt     atomic.Int64
 	mu             sync.RWMutex
 }
 
 func NewSchedulerHandler(dataOps *dataOperations.DataOperations, config *config.Config, logger *logrus.Logger) *SchedulerHandler {
-	return &SchedulerHandler{
-		dataOps:   dataOps,
-		config:    config,
-		logger:    logger,
-		stopChan:  make(chan struct{}),
-		isRunning: false,
+	handler := &SchedulerHandler{
+		dataOps:    dataOps,
+		config:     config,
+		logger:     logger,
+		stopChan:   make(chan struct{}),
+		followStop: make(chan struct{}),
+		isRunning:  false,
+	}
+
+	quiet, err := parseQuietHours(config.App.QuietHoursStart, config.App.QuietHoursEnd)
+	if err != nil {
+		logger.WithError(err).Error("Invalid quiet hours configuration, quiet hours disabled")
+	}
+	handler.quietHours = quiet
+
+	mode, ok := schedulerMode(config.App.SchedulerMode)
+	if !ok {
+		logger.WithField("mode", config.App.SchedulerMode).Error("Invalid scheduler mode, using ticker")
+	}
+	handler.mode = mode
+
+	backend, ok := queueBackend(config.App.QueueBackend)
+	if !ok {
+		logger.WithField("queue_backend", config.App.QueueBackend).Error("Invalid queue backend, using mongo")
+	}
+	handler.queueBackend = backend
+
+	handler.interval.Store(int64(config.App.SchedulerInterval))
+	handler.batchSize.Store(int64(config.App.MessagesPerInterval))
+
+	return handler
+}
+
+func schedulerMode(mode string) (string, bool) {
+	switch mode {
+	case config.SchedulerModeTicker, config.SchedulerModeStream:
+		return mode, true
+	default:
+		return config.SchedulerModeTicker, false
+	}
+}
+
+func queueBackend(backend string) (string, bool) {
+	switch backend {
+	case config.QueueBackendMongo, config.QueueBackendRedisStream:
+		return backend, true
+	default:
+		return config.QueueBackendMongo, false
 	}
 }
 
@@ -41,7 +103,7 @@ func (h *SchedulerHandler) SetWebhookHandler(webhookHandler *WebhookHandler) {
 
 // StartScheduler godoc
 // @Summary Start the message scheduler
-// @Description Start the automatic message sending scheduler
+// @Description Start the automatic message sending scheduler on all instances. Other instances follow within one leader lease renewal.
 // @Tags scheduler
 // @Accept json
 // @Produce json
@@ -53,7 +115,16 @@ func (h *SchedulerHandler) StartScheduler(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if h.isRunning {
+	active, err := h.dataOps.IsSchedulerActive()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read scheduler state")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start scheduler",
+		})
+		return
+	}
+
+	if active {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Scheduler is already running",
 		})
@@ -70,23 +141,172 @@ func (h *SchedulerHandler) StartScheduler(c *gin.Context) {
 	}
 
 	h.currentStartID = startID
-	h.startScheduler()
+	if !h.isRunning {
+		h.startScheduler()
+	}
 
 	h.logger.Info("Scheduler started successfully")
 
 	now := time.Now()
 	response := models.SchedulerResponse{
-		IsActive:  true,
-		StartedAt: &now,
-		Message:   "Scheduler started successfully",
+		IsActive:   true,
+		StartID:    startID,
+		StartedAt:  &now,
+		InstanceID: h.config.App.InstanceID,
+		Leader:     h.currentLeader(),
+		Message:    "Scheduler started successfully",
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// GetSchedulerStatus godoc
+// @Summary Get the message scheduler status
+// @Description Report whether the scheduler is running on this instance, the current leader, its settings and live counters
+// @Tags scheduler
+// @Produce json
+// @Success 200 {object} models.SchedulerResponse
+// @Router /scheduler/status [get]
+func (h *SchedulerHandler) GetSchedulerStatus(c *gin.Context) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	c.JSON(http.StatusOK, h.statusResponse())
+}
+
+// UpdateSchedulerConfig godoc
+// @Summary Change the scheduler interval and batch size
+// @Description Change the scheduler settings at runtime. A running ticker is reset without interrupting in-flight jobs, and the change is recorded in the scheduler logs. Other instances pick it up on their next leader lease renewal.
+// @Tags scheduler
+// @Accept json
+// @Produce json
+// @Param request body models.SchedulerConfigRequest true "Settings to change"
+// @Success 200 {object} models.SchedulerResponse
+// @Failure 400 {object} gin.H
+// @Failure 500 {object} gin.H
+// @Router /scheduler/config [patch]
+func (h *SchedulerHandler) UpdateSchedulerConfig(c *gin.Context) {
+	var request models.SchedulerConfigRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Warn("Invalid scheduler config request body")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if request.Interval == nil && request.MessagesPerInterval == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "At least one of interval or messages_per_interval is required",
+		})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	schedulerConfig := h.runtimeConfig()
+
+	if request.Interval != nil {
+		interval, err := time.ParseDuration(*request.Interval)
+		if err != nil || interval < time.Second {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid interval, must be a duration of at least 1s (e.g. 30s, 2m)",
+			})
+			return
+		}
+		schedulerConfig.Interval = interval
+	}
+
+	if request.MessagesPerInterval != nil {
+		if *request.MessagesPerInterval < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid messages_per_interval, must be a positive integer",
+			})
+			return
+		}
+		schedulerConfig.MessagesPerInterval = *request.MessagesPerInterval
+	}
+
+	if err := h.dataOps.SaveSchedulerConfig(schedulerConfig); err != nil {
+		h.logger.WithError(err).Error("Failed to save scheduler config")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update scheduler config",
+		})
+		return
+	}
+
+	if err := h.dataOps.CreateSchedulerConfigLog(h.currentStartID, schedulerConfig); err != nil {
+		h.logger.WithError(err).Error("Failed to create scheduler config update log")
+	}
+
+	var ticker *time.Ticker
+	if h.isRunning {
+		ticker = h.ticker
+	}
+	h.applyConfig(ticker, schedulerConfig)
+
+	c.JSON(http.StatusOK, h.statusResponse())
+}
+
+func (h *SchedulerHandler) statusResponse() models.SchedulerResponse {
+	interval := h.currentInterval()
+
+	response := models.SchedulerResponse{
+		IsActive:            h.isRunning,
+		InstanceID:          h.config.App.InstanceID,
+		Leader:              h.currentLeader(),
+		Mode:                h.mode,
+		QueueBackend:        h.queueBackend,
+		Interval:            interval.String(),
+		MessagesPerInterval: int(h.batchSize.Load()),
+		Message:             "Scheduler is stopped",
+	}
+
+	// Acknowledged entries are deleted, so the stream holds the messages that
+	// are waiting to be read or being processed.
+	if h.queueBackend == config.QueueBackendRedisStream {
+		depth, err := h.dataOps.GetMessageQueueLength()
+		if err != nil {
+			h.logger.WithError(err).Warn("Failed to get message queue length")
+		} else {
+			response.StreamDepth = &depth
+		}
+	}
+
+	if h.pool != nil {
+		stats := h.pool.stats()
+		response.InFlightJobs = stats.ActiveWorkers
+		response.WorkerPool = &stats
+	}
+
+	if h.isRunning {
+		startedAt := h.startedAt
+
+		if lastTick := h.lastTickAt.Load(); lastTick != 0 {
+			lastTickAt := time.Unix(0, lastTick)
+			response.LastTickAt = &lastTickAt
+		}
+
+		response.StartID = h.currentStartID
+		response.StartedAt = &startedAt
+		// Named schedules replace the fixed interval while any is enabled.
+		if h.hasSchedules.Load() {
+			response.NextTickAt = h.nextScheduleRun(time.Now())
+		} else if h.mode == config.SchedulerModeTicker {
+			nextTickAt := time.Unix(0, h.nextTickAt.Load())
+			response.NextTickAt = &nextTickAt
+		}
+		response.Schedules = h.scheduleNames()
+		response.Message = "Scheduler is running"
+	}
+
+	return response
+}
+
 // StopScheduler godoc
 // @Summary Stop the message scheduler
-// @Description Stop the automatic message sending scheduler
+// @Description Stop the automatic message sending scheduler on all instances. Other instances follow within one leader lease renewal.
 // @Tags scheduler
 // @Accept json
 // @Produce json
@@ -98,14 +318,23 @@ func (h *SchedulerHandler) StopScheduler(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if !h.isRunning {
+	lastLog, err := h.dataOps.GetLastSchedulerLog()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read scheduler state")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to stop scheduler",
+		})
+		return
+	}
+
+	if lastLog == nil || lastLog.Action != models.SchedulerActionStart {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Scheduler is not running",
 		})
 		return
 	}
 
-	if err := h.dataOps.StopScheduler(h.currentStartID); err != nil {
+	if err := h.dataOps.StopScheduler(lastLog.ID); err != nil {
 		h.logger.WithError(err).Error("Failed to create scheduler stop log")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to stop scheduler",
@@ -113,32 +342,91 @@ func (h *SchedulerHandler) StopScheduler(c *gin.Context) {
 		return
 	}
 
-	h.stopScheduler()
+	if h.isRunning {
+		h.stopScheduler()
+	}
 
 	h.logger.Info("Scheduler stopped successfully")
 
 	now := time.Now()
 	response := models.SchedulerResponse{
-		IsActive:  false,
-		StoppedAt: &now,
-		Message:   "Scheduler stopped successfully",
+		IsActive:   false,
+		StoppedAt:  &now,
+		InstanceID: h.config.App.InstanceID,
+		Leader:     h.currentLeader(),
+		Message:    "Scheduler stopped successfully",
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// startScheduler runs the ticker loop on every instance, but only the
+// instance holding the leader lease in Redis processes messages on a tick.
+// The lease is renewed well before it expires on its own goroutine, so that a
+// slow tick cannot hold up the renewal, and a follower takes over within one
+// lease TTL after the leader goes away.
 func (h *SchedulerHandler) startScheduler() {
 	h.isRunning = true
 	h.stopChan = make(chan struct{})
-	h.ticker = time.NewTicker(h.config.App.SchedulerInterval)
+	h.startedAt = time.Now()
+	h.lastTickAt.Store(0)
+	h.ticker = time.NewTicker(h.currentInterval())
+	h.nextTickAt.Store(h.startedAt.Add(h.currentInterval()).UnixNano())
+	ticker := h.ticker
+	h.pool = newWorkerPool(
+		max(h.config.App.WorkerConcurrency, 1),
+		max(h.config.App.WorkerQueueSize, 1),
+		h.config.App.SendRatePerSecond,
+		func(message models.Message) {
+			h.processSingleMessage(context.Background(), message)
+		},
+	)
+
+	h.campaign()
+	h.syncConfig(ticker)
+	h.startSchedules()
+
+	if h.mode == config.SchedulerModeStream {
+		h.loop.Add(1)
+		go h.stream(h.stopChan)
+	}
+
+	if h.queueBackend == config.QueueBackendRedisStream {
+		h.loop.Add(1)
+		go h.consumeQueue(h.stopChan)
+	}
 
+	h.loop.Add(1)
+	go h.renewLeadership(h.stopChan)
+
+	h.loop.Add(1)
 	go func() {
-		h.logger.WithField("interval", h.config.App.SchedulerInterval).Info("Message scheduler started")
+		defer h.loop.Done()
+		h.logger.WithField("interval", h.currentInterval()).Info("Message scheduler started")
+
+		syncTicker := time.NewTicker(h.config.App.LeaderLeaseTTL / 3)
+		defer syncTicker.Stop()
 
 		for {
 			select {
-			case <-h.ticker.C:
-				h.processMessages()
+			case <-syncTicker.C:
+				h.syncConfig(ticker)
+				h.syncSchedules()
+			case tick := <-ticker.C:
+				if h.mode == config.SchedulerModeStream {
+					continue
+				}
+				h.lastTickAt.Store(tick.UnixNano())
+				h.nextTickAt.Store(tick.Add(h.currentInterval()).UnixNano())
+				if !h.leading() {
+					h.logger.Debug("Not the scheduler leader, skipping tick")
+					continue
+				}
+				if h.hasSchedules.Load() {
+					h.logger.Debug("Named schedules are active, skipping fixed interval tick")
+					continue
+				}
+				h.dispatchTick()
 			case <-h.stopChan:
 				h.logger.Info("Scheduler stopped")
 				return
@@ -147,21 +435,259 @@ func (h *SchedulerHandler) startScheduler() {
 	}()
 }
 
+// renewLeadership campaigns for the leader lease every third of its TTL until
+// stop is closed, and then gives the lease up.
+func (h *SchedulerHandler) renewLeadership(stop chan struct{}) {
+	defer h.loop.Done()
+
+	leaseTicker := time.NewTicker(h.config.App.LeaderLeaseTTL / 3)
+	defer leaseTicker.Stop()
+
+	for {
+		select {
+		case <-leaseTicker.C:
+			h.campaign()
+		case <-stop:
+			h.resignLeadership()
+			return
+		}
+	}
+}
+
+func (h *SchedulerHandler) campaign() {
+	requestedAt := time.Now()
+	acquired, err := h.dataOps.AcquireSchedulerLeadership(h.config.App.InstanceID)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to renew scheduler leadership")
+		acquired = false
+	}
+
+	if acquired {
+		h.renewedAt.Store(requestedAt.UnixNano())
+	}
+
+	if wasLeader := h.isLeader.Swap(acquired); wasLeader != acquired {
+		if acquired {
+			h.logger.WithField("instance_id", h.config.App.InstanceID).Info("Acquired scheduler leadership")
+		} else {
+			h.logger.WithField("instance_id", h.config.App.InstanceID).Warn("Lost scheduler leadership")
+		}
+	}
+}
+
+// leading reports whether this instance holds the leader lease. Leadership
+// is dropped locally once the last successful renewal is older than the
+// lease TTL, because by then the lease may have expired in Redis and been
+// taken over, even if the renewal that would say so has not returned yet.
+func (h *SchedulerHandler) leading() bool {
+	if !h.isLeader.Load() {
+		return false
+	}
+
+	renewedAt := time.Unix(0, h.renewedAt.Load())
+	if time.Since(renewedAt) < h.config.App.LeaderLeaseTTL {
+		return true
+	}
+
+	if h.isLeader.CompareAndSwap(true, false) {
+		h.logger.WithField("renewed_at", renewedAt).Warn("Scheduler leader lease was not renewed in time, stepping down")
+	}
+	return false
+}
+
+func (h *SchedulerHandler) resignLeadership() {
+	if !h.isLeader.Swap(false) {
+		return
+	}
+
+	if err := h.dataOps.ReleaseSchedulerLeadership(h.config.App.InstanceID); err != nil {
+		h.logger.WithError(err).Warn("Failed to release scheduler leadership")
+	}
+}
+
+func (h *SchedulerHandler) currentInterval() time.Duration {
+	return time.Duration(h.interval.Load())
+}
+
+func (h *SchedulerHandler) runtimeConfig() models.SchedulerConfig {
+	return models.SchedulerConfig{
+		Interval:            h.currentInterval(),
+		MessagesPerInterval: int(h.batchSize.Load()),
+	}
+}
+
+// syncConfig applies a configuration changed through another instance.
+func (h *SchedulerHandler) syncConfig(ticker *time.Ticker) {
+	schedulerConfig, err := h.dataOps.GetSchedulerConfig()
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to read scheduler config")
+		return
+	}
+
+	if schedulerConfig != nil && *schedulerConfig != h.runtimeConfig() {
+		h.applyConfig(ticker, *schedulerConfig)
+	}
+}
+
+// applyConfig resets ticker, when given, to the new interval. Jobs that are
+// already running are not affected.
+func (h *SchedulerHandler) applyConfig(ticker *time.Ticker, schedulerConfig models.SchedulerConfig) {
+	previousInterval := time.Duration(h.interval.Swap(int64(schedulerConfig.Interval)))
+	h.batchSize.Store(int64(schedulerConfig.MessagesPerInterval))
+
+	if ticker != nil && previousInterval != schedulerConfig.Interval {
+		ticker.Reset(schedulerConfig.Interval)
+		h.nextTickAt.Store(time.Now().Add(schedulerConfig.Interval).UnixNano())
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"interval":              schedulerConfig.Interval,
+		"messages_per_interval": schedulerConfig.MessagesPerInterval,
+	}).Info("Scheduler config applied")
+}
+
+func (h *SchedulerHandler) currentLeader() string {
+	leader, err := h.dataOps.GetSchedulerLeader()
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to get scheduler leader")
+	}
+	return leader
+}
+
 func (h *SchedulerHandler) stopScheduler() {
 	if h.ticker != nil {
 		h.ticker.Stop()
 	}
+	h.stopSchedules()
 	close(h.stopChan)
+	h.loop.Wait()
 
 	h.logger.Info("Waiting for active jobs to complete...")
-	h.activeJobs.Wait()
+	h.drainPool()
 	h.logger.Info("All jobs completed, scheduler stopped")
 
 	h.isRunning = false
 	h.currentStartID = ""
 }
 
+// Resume restarts the scheduler when the last audit entry is a start. If
+// another instance holds the leader lease the run is still alive and is
+// joined as is; otherwise the previous processes went away without the
+// scheduler being stopped and the restart is recorded once.
+func (h *SchedulerHandler) Resume() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.isRunning {
+		return nil
+	}
+
+	lastLog, err := h.dataOps.GetLastSchedulerLog()
+	if err != nil {
+		return err
+	}
+
+	if lastLog == nil || lastLog.Action != models.SchedulerActionStart {
+		return nil
+	}
+
+	leader, err := h.dataOps.GetSchedulerLeader()
+	if err != nil {
+		return err
+	}
+
+	// A lease held under our own instance ID was left behind by the previous
+	// process, which is gone, so it does not show that the run is alive.
+	if leader != "" && leader != h.config.App.InstanceID {
+		h.currentStartID = lastLog.ID
+		h.startScheduler()
+
+		h.logger.WithFields(logrus.Fields{
+			"start_id": lastLog.ID,
+			"leader":   leader,
+		}).Info("Joined running scheduler")
+		return nil
+	}
+
+	previousStartID := lastLog.ID
+	startID, err := h.dataOps.RestartScheduler(previousStartID)
+	if errors.Is(err, dataOperations.ErrSchedulerAlreadyResumed) {
+		// Another instance recorded the restart; its start is the run to join.
+		if lastLog, err = h.dataOps.GetLastSchedulerLog(); err != nil {
+			return err
+		}
+		if lastLog == nil || lastLog.Action != models.SchedulerActionStart {
+			return nil
+		}
+		startID = lastLog.ID
+	} else if err != nil {
+		return err
+	}
+
+	h.currentStartID = startID
+	h.startScheduler()
+
+	h.logger.WithFields(logrus.Fields{
+		"start_id":          startID,
+		"previous_start_id": previousStartID,
+	}).Info("Scheduler resumed after restart")
+
+	return nil
+}
+
+// Follow keeps this instance in line with the scheduler state in the audit
+// trail, so that a start or stop received by any instance applies to all of
+// them. The state is checked every LeaderLeaseTTL / 3 until GracefulStop.
+func (h *SchedulerHandler) Follow() {
+	h.following.Add(1)
+	go func() {
+		defer h.following.Done()
+
+		ticker := time.NewTicker(h.config.App.LeaderLeaseTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				h.followDesiredState()
+			case <-h.followStop:
+				return
+			}
+		}
+	}()
+}
+
+func (h *SchedulerHandler) followDesiredState() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	lastLog, err := h.dataOps.GetLastSchedulerLog()
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to read scheduler state")
+		return
+	}
+
+	active := lastLog != nil && lastLog.Action == models.SchedulerActionStart
+
+	switch {
+	case active && !h.isRunning:
+		h.currentStartID = lastLog.ID
+		h.startScheduler()
+		h.logger.WithField("start_id", lastLog.ID).Info("Scheduler started by another instance")
+	case active:
+		h.currentStartID = lastLog.ID
+	case h.isRunning:
+		h.stopScheduler()
+		h.logger.Info("Scheduler stopped by another instance")
+	}
+}
+
+// GracefulStop drains the scheduler on shutdown. No stop entry is written,
+// so the run stays active in the audit trail and is resumed on next boot.
 func (h *SchedulerHandler) GracefulStop() {
+	close(h.followStop)
+	h.following.Wait()
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -171,37 +697,108 @@ func (h *SchedulerHandler) GracefulStop() {
 
 	h.logger.Info("Gracefully stopping scheduler...")
 
-	if err := h.dataOps.StopScheduler(h.currentStartID); err != nil {
-		h.logger.WithError(err).Error("Failed to create scheduler stop log during graceful shutdown")
-	}
-
 	h.stopScheduler()
 
 	h.logger.Info("Scheduler stopped gracefully")
 }
 
-func (h *SchedulerHandler) processMessages() {
-	ctx := context.Background()
-	// fmt.Println("Processing messages e geldi")
-	messages, err := h.dataOps.GetPendingMessages(h.config.App.MessagesPerInterval)
+// drainPool stops the worker pool once nothing submits to it anymore. Sends
+// in progress are completed; queued messages are returned to pending.
+func (h *SchedulerHandler) drainPool() {
+	unstarted := h.pool.stop()
+	h.pool = nil
+
+	if len(unstarted) == 0 {
+		return
+	}
+
+	ids := make([]string, len(unstarted))
+	for i, message := range unstarted {
+		ids[i] = message.ID
+	}
+
+	released, err := h.dataOps.ReleaseClaims(h.config.App.InstanceID, ids)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to get pending messages")
+		h.logger.WithError(err).Error("Failed to return queued messages to pending")
 		return
 	}
 
+	h.logger.WithField("message_count", released).Info("Returned queued messages to pending")
+}
+
+// dispatchTick sends up to MessagesPerInterval messages. The Redis stream
+// consumer draws from the same budget until the next tick, so the limit per
+// interval holds whichever path finds a message first.
+func (h *SchedulerHandler) dispatchTick() {
+	batchSize := int(h.batchSize.Load())
+	h.budget.reset(batchSize)
+
+	limit, generation := h.budget.take(batchSize)
+	claimed := h.processMessages(limit, models.MessageFilter{})
+	h.budget.giveBack(limit-claimed, generation)
+}
+
+// processMessages claims at most as many messages as the worker pool can
+// queue, so that claimed messages do not wait in memory while their lease
+// runs out. It returns how many were claimed.
+func (h *SchedulerHandler) processMessages(limit int, filter models.MessageFilter) int {
+	h.reapExpiredClaims()
+	h.expireMessages()
+	return h.claimMessages(limit, filter)
+}
+
+// claimMessages queues up to limit due messages for the worker pool and
+// returns how many were claimed.
+func (h *SchedulerHandler) claimMessages(limit int, filter models.MessageFilter) int {
+	limit = min(limit, h.pool.free())
+	if limit <= 0 {
+		h.logger.Debug("Worker queue is full, skipping claim")
+		return 0
+	}
+
+	messages, err := h.dataOps.ClaimPendingMessages(h.config.App.InstanceID, limit, filter)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to claim pending messages")
+		if len(messages) == 0 {
+			return 0
+		}
+	}
+
 	if len(messages) == 0 {
 		h.logger.Debug("No pending messages to process")
-		return
+		return 0
 	}
 
 	h.logger.WithField("message_count", len(messages)).Info("Processing messages")
 
 	for _, message := range messages {
-		h.activeJobs.Add(1)
-		go func(msg models.Message) {
-			defer h.activeJobs.Done()
-			h.processSingleMessage(ctx, msg)
-		}(message)
+		h.pool.submit(message)
+	}
+
+	return len(messages)
+}
+
+func (h *SchedulerHandler) reapExpiredClaims() {
+	released, err := h.dataOps.ReleaseExpiredClaims()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to release expired message claims")
+		return
+	}
+
+	if released > 0 {
+		h.logger.WithField("message_count", released).Warn("Returned messages with expired leases to pending")
+	}
+}
+
+func (h *SchedulerHandler) expireMessages() {
+	expired, err := h.dataOps.ExpirePendingMessages()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to expire pending messages")
+		return
+	}
+
+	if expired > 0 {
+		h.logger.WithField("message_count", expired).Warn("Expired pending messages that were not sent in time")
 	}
 }
 
@@ -211,6 +808,49 @@ func (h *SchedulerHandler) processSingleMessage(ctx context.Context, message mod
 		"to":         message.To,
 	})
 
+	if message.ExpiresAt != nil && !time.Now().Before(*message.ExpiresAt) {
+		logger.WithField("expires_at", *message.ExpiresAt).Warn("Message expired before it could be sent")
+		if err := h.dataOps.UpdateMessageStatus(message, models.MessageStatusExpired, nil); err != nil {
+			logger.WithError(err).Error("Failed to update message status to expired")
+		}
+		return
+	}
+
+	until, deferred, err := h.quietHoursDeferral(message)
+	if err != nil {
+		until = time.Now().Add(unknownTimezoneDelay)
+		logger.WithError(err).WithField("deferred_until", until).Error("Cannot check quiet hours for the recipient, deferring message")
+		if err := h.dataOps.DeferMessage(message, until, models.DeferredReasonQuietHours); err != nil {
+			logger.WithError(err).Error("Failed to defer message")
+		}
+		return
+	}
+
+	if deferred {
+		logger.WithField("deferred_until", until).Info("Recipient is in quiet hours, deferring message")
+		if err := h.dataOps.DeferMessage(message, until, models.DeferredReasonQuietHours); err != nil {
+			logger.WithError(err).Error("Failed to defer message")
+		}
+		return
+	}
+
+	release, held := h.holdBack(logger, message)
+	if held {
+		return
+	}
+
+	// The lease may have run out while the message waited in the pool, in
+	// which case another claim now owns it and is going to send it.
+	if err := h.dataOps.RenewClaim(message); err != nil {
+		release()
+		if errors.Is(err, dataOperations.ErrClaimLost) {
+			logger.Warn("Message is no longer claimed by this instance, skipping it")
+		} else {
+			logger.WithError(err).Error("Failed to renew message claim, skipping it")
+		}
+		return
+	}
+
 	logger.Info("Sending message")
 
 	webhookReq := models.WebhookRequest{
@@ -218,25 +858,163 @@ func (h *SchedulerHandler) processSingleMessage(ctx context.Context, message mod
 		Content: message.Content,
 	}
 
-	response, err := h.webhookHandler.SendMessageWithRetry(ctx, webhookReq, 3)
+	response, attempts, err := h.webhookHandler.SendMessageWithRetry(ctx, webhookReq, 3)
+	h.recordDeliveryAttempts(logger, message, attempts)
 	if err != nil {
-		logger.WithError(err).Error("Failed to send message after retries")
-
-		errorMsg := err.Error()
-		if err := h.dataOps.UpdateMessageStatus(message.ID, models.MessageStatusFailed, nil, &errorMsg); err != nil {
-			logger.WithError(err).Error("Failed to update message status to failed")
-		}
+		release()
+		h.handleSendFailure(logger, message, err)
 		return
 	}
 
 	logger.WithField("webhook_message_id", response.MessageID).Info("Message sent successfully")
 
-	if err := h.dataOps.UpdateMessageStatus(message.ID, models.MessageStatusSent, &response.MessageID, nil); err != nil {
+	if err := h.dataOps.UpdateMessageStatus(message, models.MessageStatusSent, &response.MessageID); err != nil {
 		logger.WithError(err).Error("Failed to update message status to sent")
 		return
 	}
 
-	if err := h.dataOps.CacheMessage(response.MessageID, time.Now()); err != nil {
+	if err := h.dataOps.CacheMessage(message.ID, response.MessageID, time.Now()); err != nil {
 		logger.WithError(err).Warn("Failed to cache message (non-critical)")
 	}
 }
+
+// holdBack suppresses a message that duplicates one sent within
+// DuplicateWindow and defers a message whose recipient already got
+// RecipientMaxSends within RecipientWindow. If Redis cannot be reached the
+// message is sent anyway. For a message that may be sent, release gives back
+// the duplicate window and recipient slot it took, to be called when the send
+// fails.
+func (h *SchedulerHandler) holdBack(logger *logrus.Entry, message models.Message) (release func(), held bool) {
+	var releases []func()
+	release = func() {
+		for _, releaseOne := range releases {
+			releaseOne()
+		}
+	}
+
+	if h.config.App.DuplicateWindow > 0 {
+		unique, err := h.dataOps.ClaimDuplicateWindow(message.To, message.Content, message.ID)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to check for duplicate messages, sending anyway")
+		} else if !unique {
+			logger.Warn("Identical message was sent to the recipient recently, suppressing message")
+			if err := h.dataOps.SuppressMessage(message, models.SuppressedReasonDuplicate); err != nil {
+				logger.WithError(err).Error("Failed to suppress message")
+			}
+			return nil, true
+		} else {
+			releases = append(releases, func() {
+				if err := h.dataOps.ReleaseDuplicateWindow(message.To, message.Content, message.ID); err != nil {
+					logger.WithError(err).Warn("Failed to release the duplicate window")
+				}
+			})
+		}
+	}
+
+	if h.config.App.RecipientMaxSends > 0 {
+		allowed, wait, err := h.dataOps.TakeRecipientSlot(message.To)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to check the recipient send limit, sending anyway")
+		} else if !allowed {
+			release()
+			until := time.Now().Add(wait)
+			logger.WithField("deferred_until", until).Info("Recipient send limit reached, deferring message")
+			if err := h.dataOps.DeferMessage(message, until, models.DeferredReasonRecipientThrottle); err != nil {
+				logger.WithError(err).Error("Failed to defer message")
+			}
+			return nil, true
+		} else {
+			releases = append(releases, func() {
+				if err := h.dataOps.ReturnRecipientSlot(message.To); err != nil {
+					logger.WithError(err).Warn("Failed to return the recipient send slot")
+				}
+			})
+		}
+	}
+
+	return release, false
+}
+
+// recordDeliveryAttempts appends the webhook calls of this send to the
+// message's delivery history, numbering them after the earlier ones.
+func (h *SchedulerHandler) recordDeliveryAttempts(logger *logrus.Entry, message models.Message, attempts []models.DeliveryAttempt) {
+	if len(attempts) == 0 {
+		return
+	}
+
+	for i := range attempts {
+		attempts[i].Attempt += len(message.Attempts)
+	}
+
+	if err := h.dataOps.AddDeliveryAttempts(message.ID, attempts); err != nil {
+		logger.WithError(err).Warn("Failed to record delivery attempts")
+	}
+}
+
+// handleSendFailure puts the message back in the queue with an exponential
+// backoff, or moves it to the dead-letter collection once MaxRetryCount
+// attempts are used up.
+func (h *SchedulerHandler) handleSendFailure(logger *logrus.Entry, message models.Message, sendErr error) {
+	attempts := message.RetryCount + 1
+	failure := models.MessageError{
+		Attempt:  attempts,
+		Error:    sendErr.Error(),
+		FailedAt: time.Now(),
+	}
+
+	if attempts >= h.config.App.MaxRetryCount {
+		logger.WithError(sendErr).WithField("attempts", attempts).Error("Failed to send message, retries exhausted")
+		if err := h.dataOps.MoveToDeadLetter(message, failure); err != nil {
+			logger.WithError(err).Error("Failed to move message to dead letter")
+		}
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(h.retryBackoff(message.RetryCount))
+	logger.WithError(sendErr).WithFields(logrus.Fields{
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+	}).Warn("Failed to send message, scheduling retry")
+
+	if err := h.dataOps.ScheduleRetry(message, failure, nextAttemptAt); err != nil {
+		logger.WithError(err).Error("Failed to schedule message retry")
+	}
+}
+
+// retryBackoff doubles RetryBaseDelay for every previous retry, caps it at
+// RetryMaxDelay and randomizes the upper half so that messages failing
+// together do not all come back at the same moment.
+func (h *SchedulerHandler) retryBackoff(retryCount int) time.Duration {
+	base, maxDelay := h.config.App.RetryBaseDelay, h.config.App.RetryMaxDelay
+
+	// Shifting by more than the bits that separate base from maxDelay would
+	// reach the cap anyway, and could overflow.
+	backoff := maxDelay
+	if base > 0 && base < maxDelay && retryCount < bits.Len64(uint64(maxDelay/base)) {
+		backoff = min(base<<retryCount, maxDelay)
+	}
+
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// quietHoursDeferral reports until when a non-urgent message has to wait
+// because it is night at the recipient. When the country code spans several
+// time zones the message waits while it is night in any of them. When the
+// time zone cannot be determined it returns errUnknownTimezone.
+func (h *SchedulerHandler) quietHoursDeferral(message models.Message) (time.Time, bool, error) {
+	if h.quietHours == nil || message.Priority >= models.MessagePriorityUrgent {
+		return time.Time{}, false, nil
+	}
+
+	locations, ok := recipientLocations(message)
+	if !ok {
+		return time.Time{}, false, errUnknownTimezone
+	}
+
+	until, deferred := h.quietHours.deferUntilAll(time.Now(), locations)
+	return until, deferred, nil
+}
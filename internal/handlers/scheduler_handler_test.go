@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/sinan/auto-message-sender/internal/config"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		base       time.Duration
+		maxDelay   time.Duration
+		retryCount int
+		want       time.Duration // before jitter
+	}{
+		{name: "first retry", base: time.Minute, maxDelay: time.Hour, retryCount: 0, want: time.Minute},
+		{name: "doubles", base: time.Minute, maxDelay: time.Hour, retryCount: 3, want: 8 * time.Minute},
+		{name: "last step below the cap", base: time.Minute, maxDelay: time.Hour, retryCount: 5, want: 32 * time.Minute},
+		{name: "capped", base: time.Minute, maxDelay: time.Hour, retryCount: 6, want: time.Hour},
+		{name: "shift past the word size", base: time.Minute, maxDelay: time.Hour, retryCount: 64, want: time.Hour},
+		{name: "very large retry count", base: time.Minute, maxDelay: time.Hour, retryCount: math.MaxInt, want: time.Hour},
+		{name: "large base", base: time.Duration(math.MaxInt64 / 2), maxDelay: time.Duration(math.MaxInt64), retryCount: 40, want: time.Duration(math.MaxInt64)},
+		{name: "base above the cap", base: 2 * time.Hour, maxDelay: time.Hour, retryCount: 1, want: time.Hour},
+		{name: "zero base", base: 0, maxDelay: time.Hour, retryCount: 1, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &SchedulerHandler{config: &config.Config{App: config.AppConfig{
+				RetryBaseDelay: tt.base,
+				RetryMaxDelay:  tt.maxDelay,
+			}}}
+
+			for i := 0; i < 20; i++ {
+				got := h.retryBackoff(tt.retryCount)
+				if got < tt.want/2 || got > tt.want {
+					t.Fatalf("retryBackoff(%d) = %v, want between %v and %v", tt.retryCount, got, tt.want/2, tt.want)
+				}
+			}
+		})
+	}
+}
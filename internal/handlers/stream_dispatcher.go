@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/sinan/auto-message-sender/internal/config"
+	"github.com/sinan/auto-message-sender/internal/models"
+	"time"
+)
+
+// stream keeps the worker pool's queue topped up while messages are due, so
+// that a free worker picks up the next message right away instead of waiting
+// for a tick. The pool's rate limit still applies. When nothing is due it
+// polls every StreamPollInterval; like the ticker, it leaves the work to the
+// named schedules while any are enabled. With the Redis stream queue backend
+// new messages arrive through the stream, so MongoDB is only swept every
+// SchedulerInterval for scheduled, deferred and retried messages.
+func (h *SchedulerHandler) stream(stop <-chan struct{}) {
+	defer h.loop.Done()
+
+	pollInterval := h.config.App.StreamPollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	h.logger.WithField("poll_interval", pollInterval).Info("Streaming dispatcher started")
+
+	var lastHousekeeping time.Time
+	for {
+		wait := pollInterval
+		ready := h.pool.ready
+
+		if h.leading() && !h.hasSchedules.Load() {
+			now := time.Now()
+			if now.Sub(lastHousekeeping) >= pollInterval {
+				h.reapExpiredClaims()
+				h.expireMessages()
+				lastHousekeeping = now
+			}
+
+			h.lastTickAt.Store(now.UnixNano())
+			switch {
+			case h.pool.free() == 0:
+				// Wait for a worker to take a message off the queue.
+			case h.claimMessages(h.config.App.WorkerQueueSize, models.MessageFilter{}) > 0:
+				wait = 0
+			case h.queueBackend == config.QueueBackendRedisStream:
+				wait = h.currentInterval()
+				ready = nil
+			}
+		}
+
+		if wait == 0 {
+			select {
+			case <-stop:
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ready:
+		case <-time.After(wait):
+		}
+	}
+}
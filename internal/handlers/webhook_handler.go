@@ -6,33 +6,52 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/sinan/auto-message-sender/internal/config"
+	"github.com/sinan/auto-message-sender/internal/dataOperations"
 	"github.com/sinan/auto-message-sender/internal/models"
 	"github.com/sinan/auto-message-sender/internal/validation"
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
 	"time"
+	"unicode/utf8"
 )
 
 type WebhookHandler struct {
+	dataOps    *dataOperations.DataOperations
 	config     *config.Config
 	logger     *logrus.Logger
 	httpClient *http.Client
 }
 
-func NewWebhookHandler(config *config.Config, logger *logrus.Logger) *WebhookHandler {
+func NewWebhookHandler(dataOps *dataOperations.DataOperations, config *config.Config, logger *logrus.Logger) *WebhookHandler {
 	return &WebhookHandler{
-		config: config,
-		logger: logger,
+		dataOps: dataOps,
+		config:  config,
+		logger:  logger,
 		httpClient: &http.Client{
 			Timeout: config.Webhook.Timeout,
 		},
 	}
 }
 
+// maxAttemptResponseBody caps how much of the webhook response body is kept
+// in a message's delivery history.
+const maxAttemptResponseBody = 1024
+
 func (h *WebhookHandler) SendMessage(ctx context.Context, request models.WebhookRequest) (*models.WebhookResponse, error) {
+	response, _, err := h.sendMessage(ctx, request)
+	return response, err
+}
+
+// sendMessage performs a single webhook call and describes it as a delivery
+// attempt. The attempt number and error are filled in by the caller.
+func (h *WebhookHandler) sendMessage(ctx context.Context, request models.WebhookRequest) (*models.WebhookResponse, models.DeliveryAttempt, error) {
+	attempt := models.DeliveryAttempt{
+		AttemptedAt: time.Now(),
+	}
+
 	if validationErrors := validation.ValidateWebhookRequest(request.To, request.Content); len(validationErrors) > 0 {
-		return nil, fmt.Errorf("validation failed: %v", validationErrors)
+		return nil, attempt, fmt.Errorf("validation failed: %v", validationErrors)
 	}
 
 	logger := h.logger.WithFields(logrus.Fields{
@@ -44,13 +63,13 @@ func (h *WebhookHandler) SendMessage(ctx context.Context, request models.Webhook
 	jsonData, err := json.Marshal(request)
 	if err != nil {
 		logger.WithError(err).Error("Failed to marshal request")
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, attempt, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", h.config.Webhook.URL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		logger.WithError(err).Error("Failed to create HTTP request")
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, attempt, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -60,6 +79,11 @@ func (h *WebhookHandler) SendMessage(ctx context.Context, request models.Webhook
 	resp, err := h.httpClient.Do(req)
 	duration := time.Since(startTime)
 
+	attempt.LatencyMs = duration.Milliseconds()
+	if resp != nil {
+		attempt.StatusCode = resp.StatusCode
+	}
+
 	logger = logger.WithFields(logrus.Fields{
 		"duration_ms": duration.Milliseconds(),
 		"status_code": func() int {
@@ -72,36 +96,83 @@ func (h *WebhookHandler) SendMessage(ctx context.Context, request models.Webhook
 
 	if err != nil {
 		logger.WithError(err).Error("HTTP request failed")
-		return nil, fmt.Errorf("http request failed: %w", err)
+		return nil, attempt, fmt.Errorf("http request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logger.WithError(err).Error("Failed to read response body")
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, attempt, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	attempt.ResponseBody = truncateResponseBody(body)
+
 	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
 		logger.WithFields(logrus.Fields{
 			"response_body": string(body),
 		}).Error("Webhook returned non-success status")
-		return nil, fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
+		// The body is kept, truncated, in the attempt's response_body only.
+		return nil, attempt, fmt.Errorf("webhook returned status %d", resp.StatusCode)
 	}
 
 	var webhookResponse models.WebhookResponse
 	if err := json.Unmarshal(body, &webhookResponse); err != nil {
 		logger.WithError(err).WithField("response_body", string(body)).Error("Failed to unmarshal response")
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, attempt, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	logger.WithField("message_id", webhookResponse.MessageID).Info("Webhook request successful")
 
-	return &webhookResponse, nil
+	return &webhookResponse, attempt, nil
 }
 
-func (h *WebhookHandler) SendMessageWithRetry(ctx context.Context, request models.WebhookRequest, maxRetries int) (*models.WebhookResponse, error) {
+// truncateResponseBody cuts body to maxAttemptResponseBody bytes without
+// splitting a UTF-8 encoded character.
+func truncateResponseBody(body []byte) string {
+	if len(body) <= maxAttemptResponseBody {
+		return string(body)
+	}
+
+	end := maxAttemptResponseBody
+	for end > 0 && !utf8.RuneStart(body[end]) {
+		end--
+	}
+	return string(body[:end])
+}
+
+// waitForSendToken blocks until the account-wide rate limiter in Redis allows
+// another send. When Redis cannot be reached the send goes ahead, so that an
+// outage of the limiter does not stop delivery.
+func (h *WebhookHandler) waitForSendToken(ctx context.Context) error {
+	if h.config.App.GlobalSendRate <= 0 {
+		return nil
+	}
+
+	for {
+		allowed, wait, err := h.dataOps.TakeSendToken()
+		if err != nil {
+			h.logger.WithError(err).Warn("Failed to consult the global send rate limiter, sending anyway")
+			return nil
+		}
+
+		if allowed {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// SendMessageWithRetry returns every attempt it made, numbered from 1, along
+// with the outcome of the last one.
+func (h *WebhookHandler) SendMessageWithRetry(ctx context.Context, request models.WebhookRequest, maxRetries int) (*models.WebhookResponse, []models.DeliveryAttempt, error) {
 	var lastErr error
+	attempts := make([]models.DeliveryAttempt, 0, maxRetries)
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		logger := h.logger.WithFields(logrus.Fields{
@@ -110,12 +181,22 @@ func (h *WebhookHandler) SendMessageWithRetry(ctx context.Context, request model
 			"to":          request.To,
 		})
 
-		response, err := h.SendMessage(ctx, request)
+		if err := h.waitForSendToken(ctx); err != nil {
+			return nil, attempts, err
+		}
+
+		response, deliveryAttempt, err := h.sendMessage(ctx, request)
+		deliveryAttempt.Attempt = attempt
+		if err != nil {
+			deliveryAttempt.Error = err.Error()
+		}
+		attempts = append(attempts, deliveryAttempt)
+
 		if err == nil {
 			if attempt > 1 {
 				logger.Info("Message sent successfully after retry")
 			}
-			return response, nil
+			return response, attempts, nil
 		}
 
 		lastErr = err
@@ -127,7 +208,7 @@ func (h *WebhookHandler) SendMessageWithRetry(ctx context.Context, request model
 
 			select {
 			case <-ctx.Done():
-				return nil, ctx.Err()
+				return nil, attempts, ctx.Err()
 			case <-time.After(backoffDuration):
 			}
 		}
@@ -138,5 +219,5 @@ func (h *WebhookHandler) SendMessageWithRetry(ctx context.Context, request model
 		"to":       request.To,
 	}).Error("All retry attempts failed")
 
-	return nil, fmt.Errorf("all %d attempts failed, last error: %w", maxRetries, lastErr)
+	return nil, attempts, fmt.Errorf("all %d attempts failed, last error: %w", maxRetries, lastErr)
 }
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"github.com/sinan/auto-message-sender/internal/models"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// workerPool sends claimed messages with a fixed number of workers that read
+// from a bounded queue. When a rate is set, workers share a ticker so that no
+// more than rate messages are started per second across the pool.
+type workerPool struct {
+	jobs          chan models.Message
+	ready         chan struct{}
+	quit          chan struct{}
+	rate          *time.Ticker
+	ratePerSecond int
+	process       func(models.Message)
+	wg            sync.WaitGroup
+	workers       int
+	active        atomic.Int64
+	processed     atomic.Int64
+
+	mu       sync.Mutex
+	returned []models.Message
+}
+
+func newWorkerPool(workers, queueSize, ratePerSecond int, process func(models.Message)) *workerPool {
+	pool := &workerPool{
+		jobs:          make(chan models.Message, queueSize),
+		ready:         make(chan struct{}, 1),
+		quit:          make(chan struct{}),
+		ratePerSecond: ratePerSecond,
+		process:       process,
+		workers:       workers,
+	}
+
+	if ratePerSecond > 0 && ratePerSecond <= int(time.Second) {
+		pool.rate = time.NewTicker(time.Second / time.Duration(ratePerSecond))
+	}
+
+	pool.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+
+	return pool
+}
+
+// free reports how many messages can be queued without blocking.
+func (p *workerPool) free() int {
+	return cap(p.jobs) - len(p.jobs)
+}
+
+func (p *workerPool) submit(message models.Message) {
+	p.jobs <- message
+}
+
+func (p *workerPool) work() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.quit:
+			return
+		default:
+		}
+
+		select {
+		case <-p.quit:
+			return
+		case message := <-p.jobs:
+			p.signalReady()
+
+			if !p.waitForRate() {
+				p.giveBack(message)
+				return
+			}
+
+			p.active.Add(1)
+			p.process(message)
+			p.active.Add(-1)
+			p.processed.Add(1)
+		}
+	}
+}
+
+// signalReady wakes up a dispatcher waiting for room in the queue.
+func (p *workerPool) signalReady() {
+	select {
+	case p.ready <- struct{}{}:
+	default:
+	}
+}
+
+// waitForRate returns false when the pool is stopped while waiting.
+func (p *workerPool) waitForRate() bool {
+	if p.rate == nil {
+		return true
+	}
+
+	select {
+	case <-p.rate.C:
+		return true
+	case <-p.quit:
+		return false
+	}
+}
+
+func (p *workerPool) giveBack(message models.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.returned = append(p.returned, message)
+}
+
+// stop waits for the messages that are being sent and returns the queued
+// ones that were never started. Nothing may be submitted after stop.
+func (p *workerPool) stop() []models.Message {
+	close(p.quit)
+	p.wg.Wait()
+
+	if p.rate != nil {
+		p.rate.Stop()
+	}
+
+	close(p.jobs)
+	unstarted := p.returned
+	for message := range p.jobs {
+		unstarted = append(unstarted, message)
+	}
+
+	return unstarted
+}
+
+func (p *workerPool) stats() models.WorkerPoolStats {
+	return models.WorkerPoolStats{
+		Workers:       p.workers,
+		ActiveWorkers: p.active.Load(),
+		QueueDepth:    len(p.jobs),
+		QueueCapacity: cap(p.jobs),
+		Processed:     p.processed.Load(),
+		RatePerSecond: p.ratePerSecond,
+	}
+}
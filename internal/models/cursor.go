@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SentMessagesCursor marks the last message of a page of sent messages,
+// which are ordered by sent_at and then by ID, both descending.
+type SentMessagesCursor struct {
+	SentAt time.Time `json:"sent_at"`
+	ID     string    `json:"id"`
+}
+
+// Encode returns the cursor as an opaque, URL-safe string.
+func (c SentMessagesCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeSentMessagesCursor(encoded string) (*SentMessagesCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor SentMessagesCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.SentAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// DeadLetterMessage is a message that used up all of its retries. It keeps
+// the full message, including its error history, so that it can be requeued.
+type DeadLetterMessage struct {
+	Message        `bson:",inline"`
+	DeadLetteredAt time.Time `bson:"dead_lettered_at" json:"dead_lettered_at"`
+}
+
+type DeadLetterListResponse struct {
+	Messages   []DeadLetterMessage `json:"messages"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PerPage    int                 `json:"per_page"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// RequeueDeadLetterRequest selects dead-lettered messages either by ID or by
+// filter. An empty filter matches every dead-lettered message.
+type RequeueDeadLetterRequest struct {
+	IDs    []string       `json:"ids,omitempty"`
+	Filter *MessageFilter `json:"filter,omitempty"`
+}
+
+// RequeueDeadLetterResponse lists the requeued messages. Skipped messages
+// stay dead-lettered, typically because a pending message with the same
+// idempotency key exists.
+type RequeueDeadLetterResponse struct {
+	Requeued   int      `json:"requeued"`
+	IDs        []string `json:"ids"`
+	SkippedIDs []string `json:"skipped_ids,omitempty"`
+}
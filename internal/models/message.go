@@ -1,15 +1,36 @@
 package models
 
 import (
+	"github.com/sinan/auto-message-sender/internal/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 )
 
 type MessageStatus string
 
 const (
-	MessageStatusPending MessageStatus = "pending"
-	MessageStatusSent    MessageStatus = "sent"
-	MessageStatusFailed  MessageStatus = "failed"
+	MessageStatusPending    MessageStatus = "pending"
+	MessageStatusSending    MessageStatus = "sending"
+	MessageStatusSent       MessageStatus = "sent"
+	MessageStatusFailed     MessageStatus = "failed"
+	MessageStatusCancelled  MessageStatus = "cancelled"
+	MessageStatusExpired    MessageStatus = "expired"
+	MessageStatusSuppressed MessageStatus = "suppressed"
+)
+
+const (
+	DeferredReasonQuietHours        = "quiet_hours"
+	DeferredReasonRecipientThrottle = "recipient_throttled"
+)
+
+const SuppressedReasonDuplicate = "duplicate"
+
+// Messages with a higher priority are sent first. Priorities range from
+// MessagePriorityNormal to MessagePriorityUrgent.
+const (
+	MessagePriorityNormal = 0
+	MessagePriorityHigh   = 5
+	MessagePriorityUrgent = 9
 )
 
 type Message struct {
@@ -17,11 +38,92 @@ type Message struct {
 	To         string        `bson:"to" json:"to" validate:"required,e164"`
 	Content    string        `bson:"content" json:"content" validate:"required,max=160"`
 	Status     MessageStatus `bson:"status" json:"status"`
+	Priority   int           `bson:"priority" json:"priority"`
+	Timezone   string        `bson:"timezone,omitempty" json:"timezone,omitempty"`
 	CreatedAt  time.Time     `bson:"created_at" json:"created_at"`
+	SendAt     *time.Time    `bson:"send_at,omitempty" json:"send_at,omitempty"`
+	ExpiresAt  *time.Time    `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
 	SentAt     *time.Time    `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
 	MessageID  *string       `bson:"message_id,omitempty" json:"message_id,omitempty"`
 	RetryCount int           `bson:"retry_count" json:"retry_count"`
 	Error      *string       `bson:"error,omitempty" json:"error,omitempty"`
+
+	UpdatedAt   *time.Time `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	CancelledAt *time.Time `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+
+	NextAttemptAt *time.Time     `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"`
+	ErrorHistory  []MessageError `bson:"error_history,omitempty" json:"error_history,omitempty"`
+
+	Attempts []DeliveryAttempt `bson:"attempts,omitempty" json:"attempts,omitempty"`
+
+	ClaimedBy      *string    `bson:"claimed_by,omitempty" json:"claimed_by,omitempty"`
+	ClaimID        *string    `bson:"claim_id,omitempty" json:"claim_id,omitempty"`
+	LeaseExpiresAt *time.Time `bson:"lease_expires_at,omitempty" json:"lease_expires_at,omitempty"`
+	DeferredReason *string    `bson:"deferred_reason,omitempty" json:"deferred_reason,omitempty"`
+
+	SuppressedReason *string `bson:"suppressed_reason,omitempty" json:"suppressed_reason,omitempty"`
+
+	IdempotencyKey *string `bson:"idempotency_key,omitempty" json:"idempotency_key,omitempty"`
+}
+
+// MessageError records why a single send attempt failed.
+type MessageError struct {
+	Attempt  int       `bson:"attempt" json:"attempt"`
+	Error    string    `bson:"error" json:"error"`
+	FailedAt time.Time `bson:"failed_at" json:"failed_at"`
+}
+
+// DeliveryAttempt records a single webhook call made for a message. Attempts
+// are numbered across all of the message's sends.
+type DeliveryAttempt struct {
+	Attempt      int       `bson:"attempt" json:"attempt"`
+	AttemptedAt  time.Time `bson:"attempted_at" json:"attempted_at"`
+	StatusCode   int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	LatencyMs    int64     `bson:"latency_ms" json:"latency_ms"`
+	ResponseBody string    `bson:"response_body,omitempty" json:"response_body,omitempty"`
+	Error        string    `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+type CreateMessageRequest struct {
+	To        string     `json:"to"`
+	Content   string     `json:"content"`
+	Priority  int        `json:"priority,omitempty"`
+	Timezone  string     `json:"timezone,omitempty"`
+	SendAt    *time.Time `json:"send_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdateMessageRequest changes a pending message. Fields left out are kept.
+type UpdateMessageRequest struct {
+	To      *string    `json:"to,omitempty"`
+	Content *string    `json:"content,omitempty"`
+	SendAt  *time.Time `json:"send_at,omitempty"`
+}
+
+type BatchMessageItem struct {
+	ID        string     `json:"id,omitempty"`
+	To        string     `json:"to"`
+	Content   string     `json:"content"`
+	Priority  int        `json:"priority,omitempty"`
+	Timezone  string     `json:"timezone,omitempty"`
+	SendAt    *time.Time `json:"send_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type BatchCreateMessageRequest struct {
+	Messages []BatchMessageItem `json:"messages"`
+}
+
+type BatchItemError struct {
+	Index  int                         `json:"index"`
+	Errors validation.ValidationErrors `json:"errors"`
+}
+
+type BatchCreateMessageResponse struct {
+	Accepted        int              `json:"accepted"`
+	AlreadyEnqueued int              `json:"already_enqueued"`
+	Rejected        int              `json:"rejected"`
+	Errors          []BatchItemError `json:"errors"`
 }
 
 type WebhookRequest struct {
@@ -34,10 +136,34 @@ type WebhookResponse struct {
 	MessageID string `json:"messageId"`
 }
 
+// MessageListResponse leaves out Total and TotalPages when the count was not
+// requested. NextCursor is empty once the last page has been reached.
 type MessageListResponse struct {
 	Messages   []Message `json:"messages"`
-	Total      int64     `json:"total"`
-	Page       int       `json:"page"`
+	Total      *int64    `json:"total,omitempty"`
+	Page       int       `json:"page,omitempty"`
 	PerPage    int       `json:"per_page"`
-	TotalPages int       `json:"total_pages"`
+	TotalPages *int      `json:"total_pages,omitempty"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// NewMessage creates a pending message. When sendAt is nil the message is
+// due immediately and send_at is set to the creation time, so that the
+// pending queue can always be ordered by send_at.
+func NewMessage(to, content string, priority int, sendAt *time.Time) *Message {
+	now := time.Now()
+	if sendAt == nil {
+		sendAt = &now
+	}
+
+	return &Message{
+		ID:         primitive.NewObjectID().Hex(),
+		To:         to,
+		Content:    content,
+		Status:     MessageStatusPending,
+		Priority:   priority,
+		CreatedAt:  now,
+		SendAt:     sendAt,
+		RetryCount: 0,
+	}
 }
@@ -0,0 +1,20 @@
+package models
+
+import "github.com/sinan/auto-message-sender/pkg/mongodb/filtering"
+
+// MessageFilterMetadata declares which message fields can be filtered and
+// sorted on by GET /api/v1/messages. Dates use the DD-MM-YYYY format.
+var MessageFilterMetadata = filtering.FilterMetadata{
+	FilterableFields: []filtering.FilterableField{
+		{Name: "status", Type: "string", Operations: []string{"equals"}},
+		{Name: "to", Type: "string", Operations: []string{"equals", "contains"}},
+		{Name: "created_at", Type: "date", Operations: []string{"between", "gt", "lt"}},
+		{Name: "sent_at", Type: "date", Operations: []string{"between", "gt", "lt"}},
+		{Name: "retry_count", Type: "number", Operations: []string{"equals", "between", "gt", "lt"}},
+	},
+	SortableFields: []filtering.SortableField{
+		{Name: "created_at"},
+		{Name: "sent_at"},
+		{Name: "retry_count"},
+	},
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// MessageFilter narrows down which pending messages a schedule picks up.
+// Empty fields do not restrict the selection.
+type MessageFilter struct {
+	MinPriority *int   `bson:"min_priority,omitempty" json:"min_priority,omitempty"`
+	MaxPriority *int   `bson:"max_priority,omitempty" json:"max_priority,omitempty"`
+	ToPrefix    string `bson:"to_prefix,omitempty" json:"to_prefix,omitempty"`
+}
+
+type Schedule struct {
+	Name      string        `bson:"_id" json:"name"`
+	Cron      string        `bson:"cron" json:"cron"`
+	Timezone  string        `bson:"timezone" json:"timezone"`
+	BatchSize int           `bson:"batch_size" json:"batch_size"`
+	Filter    MessageFilter `bson:"filter" json:"filter"`
+	Enabled   bool          `bson:"enabled" json:"enabled"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+	NextRunAt *time.Time    `bson:"-" json:"next_run_at,omitempty"`
+}
+
+type ScheduleRequest struct {
+	Cron      string        `json:"cron"`
+	Timezone  string        `json:"timezone"`
+	BatchSize int           `json:"batch_size"`
+	Filter    MessageFilter `json:"filter"`
+	Enabled   *bool         `json:"enabled,omitempty"`
+}
@@ -8,30 +8,79 @@ import (
 type SchedulerAction string
 
 const (
-	SchedulerActionStart SchedulerAction = "start"
-	SchedulerActionStop  SchedulerAction = "stop"
+	SchedulerActionStart        SchedulerAction = "start"
+	SchedulerActionStop         SchedulerAction = "stop"
+	SchedulerActionConfigUpdate SchedulerAction = "config_update"
 )
 
+// SchedulerLogReasonRestart marks the stop/start pair written when a new
+// process resumes a scheduler run that was active before it restarted.
+const SchedulerLogReasonRestart = "restart"
+
 type SchedulerLog struct {
-	ID        string          `bson:"_id" json:"id"`
-	Action    SchedulerAction `bson:"action" json:"action"`
-	StartID   *string         `bson:"start_id,omitempty" json:"start_id,omitempty"`
-	Timestamp time.Time       `bson:"timestamp" json:"timestamp"`
-	CreatedAt time.Time       `bson:"created_at" json:"created_at"`
+	ID          string           `bson:"_id" json:"id"`
+	Action      SchedulerAction  `bson:"action" json:"action"`
+	StartID     *string          `bson:"start_id,omitempty" json:"start_id,omitempty"`
+	ResumedFrom *string          `bson:"resumed_from,omitempty" json:"resumed_from,omitempty"`
+	Reason      string           `bson:"reason,omitempty" json:"reason,omitempty"`
+	Config      *SchedulerConfig `bson:"config,omitempty" json:"config,omitempty"`
+	Timestamp   time.Time        `bson:"timestamp" json:"timestamp"`
+	CreatedAt   time.Time        `bson:"created_at" json:"created_at"`
+}
+
+// SchedulerConfig holds the scheduler settings that can be changed at runtime.
+// It is shared by all instances through Redis.
+type SchedulerConfig struct {
+	Interval            time.Duration `bson:"interval" json:"interval"`
+	MessagesPerInterval int           `bson:"messages_per_interval" json:"messages_per_interval"`
+}
+
+type SchedulerConfigRequest struct {
+	Interval            *string `json:"interval,omitempty"`
+	MessagesPerInterval *int    `json:"messages_per_interval,omitempty"`
 }
 
 type SchedulerResponse struct {
-	IsActive  bool       `json:"is_active"`
-	StartedAt *time.Time `json:"started_at,omitempty"`
-	StoppedAt *time.Time `json:"stopped_at,omitempty"`
-	Message   string     `json:"message"`
+	IsActive            bool             `json:"is_active"`
+	StartID             string           `json:"start_id,omitempty"`
+	StartedAt           *time.Time       `json:"started_at,omitempty"`
+	StoppedAt           *time.Time       `json:"stopped_at,omitempty"`
+	InstanceID          string           `json:"instance_id"`
+	Leader              string           `json:"leader,omitempty"`
+	Mode                string           `json:"mode,omitempty"`
+	QueueBackend        string           `json:"queue_backend,omitempty"`
+	StreamDepth         *int64           `json:"stream_depth,omitempty"`
+	Interval            string           `json:"interval,omitempty"`
+	MessagesPerInterval int              `json:"messages_per_interval,omitempty"`
+	InFlightJobs        int64            `json:"in_flight_jobs"`
+	WorkerPool          *WorkerPoolStats `json:"worker_pool,omitempty"`
+	LastTickAt          *time.Time       `json:"last_tick_at,omitempty"`
+	NextTickAt          *time.Time       `json:"next_tick_at,omitempty"`
+	Schedules           []string         `json:"schedules,omitempty"`
+	Message             string           `json:"message"`
+}
+
+// WorkerPoolStats describes the pool that sends the claimed messages.
+// RatePerSecond is 0 when sends are not rate limited.
+type WorkerPoolStats struct {
+	Workers       int   `json:"workers"`
+	ActiveWorkers int64 `json:"active_workers"`
+	QueueDepth    int   `json:"queue_depth"`
+	QueueCapacity int   `json:"queue_capacity"`
+	Processed     int64 `json:"processed"`
+	RatePerSecond int   `json:"rate_per_second"`
 }
 
 type CachedMessage struct {
+	ID        string    `json:"id,omitempty"`
 	MessageID string    `json:"message_id"`
 	SentAt    time.Time `json:"sent_at"`
 }
 
+type IdempotencyRecord struct {
+	MessageID string `json:"message_id"`
+}
+
 func NewSchedulerStartLog() *SchedulerLog {
 	now := time.Now()
 	return &SchedulerLog{
@@ -53,3 +102,20 @@ func NewSchedulerStopLog(startID string) *SchedulerLog {
 		CreatedAt: now,
 	}
 }
+
+func NewSchedulerConfigUpdateLog(startID string, config SchedulerConfig) *SchedulerLog {
+	now := time.Now()
+	log := &SchedulerLog{
+		ID:        primitive.NewObjectID().Hex(),
+		Action:    SchedulerActionConfigUpdate,
+		Config:    &config,
+		Timestamp: now,
+		CreatedAt: now,
+	}
+
+	if startID != "" {
+		log.StartID = &startID
+	}
+
+	return log
+}
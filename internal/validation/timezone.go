@@ -0,0 +1,129 @@
+package validation
+
+import "strings"
+
+// countryCodeTimezones maps E.164 calling codes to the time zones in use
+// behind them. Countries spanning several zones list each of them, so that
+// callers can hold a message back while it is night in any of them. Longer
+// prefixes split off parts of a code whose local time differs a lot from the
+// rest, such as Hawaii, Alaska and the Pacific territories under +1.
+var countryCodeTimezones = map[string][]string{
+	"1": {
+		"America/Puerto_Rico",
+		"America/New_York",
+		"America/Chicago",
+		"America/Denver",
+		"America/Phoenix",
+		"America/Los_Angeles",
+	},
+	"1670": {"Pacific/Saipan"},
+	"1671": {"Pacific/Guam"},
+	"1684": {"Pacific/Pago_Pago"},
+	"1709": {"America/St_Johns"},
+	"1808": {"Pacific/Honolulu"},
+	"1879": {"America/St_Johns"},
+	"1907": {"America/Anchorage"},
+	"7": {
+		"Europe/Kaliningrad",
+		"Europe/Moscow",
+		"Europe/Samara",
+		"Asia/Yekaterinburg",
+		"Asia/Omsk",
+		"Asia/Novosibirsk",
+		"Asia/Krasnoyarsk",
+		"Asia/Irkutsk",
+		"Asia/Yakutsk",
+		"Asia/Vladivostok",
+		"Asia/Magadan",
+		"Asia/Kamchatka",
+		"Asia/Almaty",
+	},
+	"20":  {"Africa/Cairo"},
+	"27":  {"Africa/Johannesburg"},
+	"30":  {"Europe/Athens"},
+	"31":  {"Europe/Amsterdam"},
+	"32":  {"Europe/Brussels"},
+	"33":  {"Europe/Paris"},
+	"34":  {"Atlantic/Canary", "Europe/Madrid"},
+	"36":  {"Europe/Budapest"},
+	"39":  {"Europe/Rome"},
+	"40":  {"Europe/Bucharest"},
+	"41":  {"Europe/Zurich"},
+	"43":  {"Europe/Vienna"},
+	"44":  {"Europe/London"},
+	"45":  {"Europe/Copenhagen"},
+	"46":  {"Europe/Stockholm"},
+	"47":  {"Europe/Oslo"},
+	"48":  {"Europe/Warsaw"},
+	"49":  {"Europe/Berlin"},
+	"52":  {"America/Tijuana", "America/Hermosillo", "America/Mexico_City", "America/Cancun"},
+	"55":  {"America/Rio_Branco", "America/Manaus", "America/Sao_Paulo", "America/Noronha"},
+	"61":  {"Australia/Perth", "Australia/Darwin", "Australia/Adelaide", "Australia/Brisbane", "Australia/Sydney"},
+	"62":  {"Asia/Jakarta", "Asia/Makassar", "Asia/Jayapura"},
+	"63":  {"Asia/Manila"},
+	"64":  {"Pacific/Auckland", "Pacific/Chatham"},
+	"65":  {"Asia/Singapore"},
+	"66":  {"Asia/Bangkok"},
+	"81":  {"Asia/Tokyo"},
+	"82":  {"Asia/Seoul"},
+	"84":  {"Asia/Ho_Chi_Minh"},
+	"86":  {"Asia/Shanghai"},
+	"90":  {"Europe/Istanbul"},
+	"91":  {"Asia/Kolkata"},
+	"92":  {"Asia/Karachi"},
+	"351": {"Atlantic/Azores", "Europe/Lisbon"},
+	"353": {"Europe/Dublin"},
+	"358": {"Europe/Helsinki"},
+	"359": {"Europe/Sofia"},
+	"380": {"Europe/Kiev"},
+	"420": {"Europe/Prague"},
+	"297": {"America/Aruba"},
+	"299": {"America/Nuuk"},
+	"682": {"Pacific/Rarotonga"},
+	"683": {"Pacific/Niue"},
+	"689": {"Pacific/Tahiti"},
+	"966": {"Asia/Riyadh"},
+	"971": {"Asia/Dubai"},
+	"972": {"Asia/Jerusalem"},
+	"994": {"Asia/Baku"},
+	"995": {"Asia/Tbilisi"},
+}
+
+// worldZoneTimezones spans each ITU world numbering zone, the first digit of
+// a calling code, from its westernmost to its easternmost time zone. It is the
+// fallback for calling codes missing from countryCodeTimezones, so that their
+// messages are held back while it is night anywhere in the region. Codes far
+// off the rest of their zone, such as +297 or +689, are listed above instead.
+// Zones 1 and 7 are covered by countryCodeTimezones.
+var worldZoneTimezones = map[byte][]string{
+	'2': {"Atlantic/Cape_Verde", "Africa/Abidjan", "Africa/Lagos", "Africa/Cairo", "Africa/Nairobi", "Indian/Mauritius"},
+	'3': {"Atlantic/Azores", "Europe/Lisbon", "Europe/Paris", "Europe/Athens", "Europe/Minsk"},
+	'4': {"Europe/London", "Europe/Berlin", "Europe/Bucharest"},
+	'5': {"America/Tijuana", "America/Mexico_City", "America/Bogota", "America/Santiago", "America/Sao_Paulo", "America/Noronha"},
+	'6': {"Asia/Jakarta", "Asia/Singapore", "Australia/Sydney", "Pacific/Auckland", "Pacific/Tongatapu", "Pacific/Kiritimati"},
+	'8': {"Asia/Dhaka", "Asia/Shanghai", "Asia/Tokyo"},
+	'9': {"Europe/Istanbul", "Asia/Tehran", "Asia/Dubai", "Asia/Karachi", "Asia/Kolkata", "Asia/Yangon", "Asia/Ulaanbaatar"},
+}
+
+// maxTimezonePrefix is the longest key in countryCodeTimezones.
+const maxTimezonePrefix = 4
+
+// TimezonesForPhoneNumber returns the time zones the recipient of an E.164
+// number may be in. The longest matching prefix wins; calling codes without
+// an entry get every time zone of their world numbering zone.
+func TimezonesForPhoneNumber(phone string) ([]string, bool) {
+	digits := strings.TrimPrefix(phone, "+")
+
+	for length := min(maxTimezonePrefix, len(digits)); length >= 1; length-- {
+		if timezones, ok := countryCodeTimezones[digits[:length]]; ok {
+			return timezones, true
+		}
+	}
+
+	if digits == "" {
+		return nil, false
+	}
+
+	timezones, ok := worldZoneTimezones[digits[0]]
+	return timezones, ok
+}
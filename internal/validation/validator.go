@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var (
-	phoneRegex = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	phoneRegex       = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	phonePrefixRegex = regexp.MustCompile(`^\+[1-9]\d{0,13}$`)
+	identifierRegex  = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
 )
 
 type ValidationError struct {
@@ -49,6 +52,62 @@ func ValidateMessageContent(content string) error {
 	return nil
 }
 
+func ValidatePriority(priority int) error {
+	if priority < 0 || priority > 9 {
+		return fmt.Errorf("priority must be between 0 and 9")
+	}
+
+	return nil
+}
+
+func ValidateMessageID(id string) error {
+	if !identifierRegex.MatchString(id) {
+		return fmt.Errorf("id must be 1-64 characters of letters, digits, '-' or '_'")
+	}
+
+	return nil
+}
+
+func ValidateProviderMessageID(id string) error {
+	if id == "" || len(id) > 255 {
+		return fmt.Errorf("message_id must be 1-255 characters")
+	}
+
+	return nil
+}
+
+func ValidateScheduleName(name string) error {
+	if !identifierRegex.MatchString(name) {
+		return fmt.Errorf("name must be 1-64 characters of letters, digits, '-' or '_'")
+	}
+
+	return nil
+}
+
+func ValidatePhonePrefix(prefix string) error {
+	if !phonePrefixRegex.MatchString(prefix) {
+		return fmt.Errorf("phone prefix must start with '+' followed by digits (e.g., +90)")
+	}
+
+	return nil
+}
+
+func ValidateTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("unknown IANA time zone %q", timezone)
+	}
+
+	return nil
+}
+
+func ValidateIdempotencyKey(key string) error {
+	if len(key) > 255 {
+		return fmt.Errorf("idempotency key must be 255 characters or less")
+	}
+
+	return nil
+}
+
 func ValidateWebhookRequest(to, content string) ValidationErrors {
 	var errors ValidationErrors
 
@@ -0,0 +1,269 @@
+// Package cron parses cron expressions and computes their next activation
+// time. Expressions have either five fields (minute hour day-of-month month
+// day-of-week) or six, with a leading seconds field:
+//
+//	*/30 * 8-19 * * MON-FRI   every 30 seconds on weekdays from 08:00 to 19:59
+//
+// Each field accepts "*", "?", single values, ranges (a-b), steps (*/n, a-b/n,
+// a/n) and comma separated lists. Months and weekdays also accept three-letter
+// names. The descriptors @yearly, @monthly, @weekly, @daily and @hourly are
+// supported as well.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule struct {
+	second, minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar record whether the day fields were left
+	// unrestricted. When both are restricted a day matches if either does.
+	domStar, dowStar bool
+}
+
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondBounds = bounds{min: 0, max: 59}
+	minuteBounds = bounds{min: 0, max: 59}
+	hourBounds   = bounds{min: 0, max: 23}
+	domBounds    = bounds{min: 1, max: 31}
+	monthBounds  = bounds{min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Both 0 and 7 stand for Sunday.
+	dowBounds = bounds{min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if descriptor, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("expected 5 or 6 fields, got %d", len(fields))
+	}
+
+	schedule := &Schedule{
+		domStar: isStar(fields[3]),
+		dowStar: isStar(fields[5]),
+	}
+
+	var err error
+	if schedule.second, err = parseField(fields[0], secondBounds); err != nil {
+		return nil, fmt.Errorf("seconds: %w", err)
+	}
+	if schedule.minute, err = parseField(fields[1], minuteBounds); err != nil {
+		return nil, fmt.Errorf("minutes: %w", err)
+	}
+	if schedule.hour, err = parseField(fields[2], hourBounds); err != nil {
+		return nil, fmt.Errorf("hours: %w", err)
+	}
+	if schedule.dom, err = parseField(fields[3], domBounds); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if schedule.month, err = parseField(fields[4], monthBounds); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if schedule.dow, err = parseField(fields[5], dowBounds); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1 << 0
+	}
+
+	return schedule, nil
+}
+
+// Next returns the first activation strictly after t, in t's location. It
+// returns the zero time if the schedule never fires within five years, e.g.
+// for "0 0 30 2 *".
+//
+// Fields are matched against wall clock time. An activation that falls into a
+// daylight saving gap runs at the same wall clock time in the offset used
+// before the change, so "30 2 * * *" runs at 03:30 on the day clocks skip from
+// 02:00 to 03:00. When clocks go back, the repeated hour only fires once.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	wall := wallClock(t)
+
+	for {
+		wall = s.nextWall(wall)
+		if wall.IsZero() {
+			return time.Time{}
+		}
+
+		next := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+		if gap := wall.Sub(wallClock(next)); gap > 0 {
+			next = next.Add(gap)
+		}
+
+		if next.After(t) {
+			return next
+		}
+	}
+}
+
+// wallClock returns t's wall clock time as a UTC time, which has no daylight
+// saving transitions to skip or repeat.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// nextWall returns the first wall clock time strictly after wall that matches
+// the schedule. wall must be in UTC.
+func (s *Schedule) nextWall(wall time.Time) time.Time {
+	t := wall.Add(time.Second)
+	yearLimit := t.Year() + 5
+
+search:
+	for t.Year() <= yearLimit {
+		for s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			if t.Month() == time.January {
+				continue search
+			}
+		}
+
+		for !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			if t.Day() == 1 {
+				continue search
+			}
+		}
+
+		for s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+			if t.Hour() == 0 {
+				continue search
+			}
+		}
+
+		for s.minute&(1<<uint(t.Minute())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, time.UTC)
+			if t.Minute() == 0 {
+				continue search
+			}
+		}
+
+		for s.second&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			if t.Second() == 0 {
+				continue search
+			}
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func isStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := uint(1)
+		if hasStep {
+			value, err := strconv.ParseUint(stepPart, 10, 8)
+			if err != nil || value == 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = uint(value)
+		}
+
+		var low, high uint
+		switch {
+		case isStar(rangePart):
+			low, high = b.min, b.max
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(lowPart, b); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(highPart, b); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := parseValue(rangePart, b)
+			if err != nil {
+				return 0, err
+			}
+			low, high = value, value
+			if hasStep {
+				high = b.max
+			}
+		}
+
+		if low > high {
+			return 0, fmt.Errorf("invalid range %q", rangePart)
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(value string, b bounds) (uint, error) {
+	if number, ok := b.names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+
+	number, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+
+	if uint(number) < b.min || uint(number) > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", number, b.min, b.max)
+	}
+
+	return uint(number), nil
+}
@@ -0,0 +1,170 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every minute",
+			expr: "* * * * *",
+			from: time.Date(2026, 1, 1, 10, 0, 30, 500, time.UTC),
+			want: time.Date(2026, 1, 1, 10, 1, 0, 0, time.UTC),
+		},
+		{
+			name: "seconds field",
+			expr: "*/30 * * * * *",
+			from: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 10, 0, 30, 0, time.UTC),
+		},
+		{
+			name: "weekdays only",
+			expr: "0 9 * * MON-FRI",
+			from: time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC), // Friday
+			want: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "seven is sunday",
+			expr: "0 0 * * 7",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), // Thursday
+			want: time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "zero step seven is sunday",
+			expr: "0 0 * * 0/7",
+			from: time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC), // Sunday
+			want: time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 15 * MON",
+			from: time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC), // Tuesday
+			want: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never fires",
+			expr: "0 0 30 2 *",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+		{
+			name: "spring forward daily inside gap",
+			expr: "30 2 * * *",
+			from: time.Date(2026, 3, 8, 1, 0, 0, 0, newYork),
+			want: time.Date(2026, 3, 8, 3, 30, 0, 0, newYork),
+		},
+		{
+			name: "spring forward hourly across gap",
+			expr: "0 * * * *",
+			from: time.Date(2026, 3, 8, 1, 59, 30, 0, newYork),
+			want: time.Date(2026, 3, 8, 3, 0, 0, 0, newYork),
+		},
+		{
+			name: "spring forward after gap",
+			expr: "30 2 * * *",
+			from: time.Date(2026, 3, 8, 3, 30, 0, 0, newYork),
+			want: time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+		},
+		{
+			name: "fall back first occurrence",
+			expr: "30 1 * * *",
+			from: time.Date(2026, 11, 1, 0, 0, 0, 0, newYork),
+			want: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+		},
+		{
+			name: "fall back does not repeat",
+			expr: "30 1 * * *",
+			from: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC).In(newYork), // 01:30 EDT
+			want: time.Date(2026, 11, 2, 1, 30, 0, 0, newYork),
+		},
+		{
+			name: "fall back hourly",
+			expr: "0 * * * *",
+			from: time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC).In(newYork), // 02:00 EDT, becomes 01:00 EST
+			want: time.Date(2026, 11, 1, 2, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+
+			got := schedule.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Fatalf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+			if !got.IsZero() && !got.After(tt.from) {
+				t.Fatalf("Next(%v) = %v, not after its input", tt.from, got)
+			}
+		})
+	}
+}
+
+func TestNextAlwaysAdvances(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	for _, expr := range []string{"* * * * *", "*/15 * * * *", "0 * * * *", "30 2 * * *", "30 1 * * *"} {
+		schedule, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", expr, err)
+		}
+
+		for _, from := range []time.Time{
+			time.Date(2026, 3, 7, 23, 0, 0, 0, newYork),
+			time.Date(2026, 10, 31, 23, 0, 0, 0, newYork),
+		} {
+			current := from
+			for current.Before(from.Add(6 * time.Hour)) {
+				next := schedule.Next(current)
+				if !next.After(current) {
+					t.Fatalf("%q: Next(%v) = %v, not after its input", expr, current, next)
+				}
+				current = next
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", expr)
+		}
+	}
+}
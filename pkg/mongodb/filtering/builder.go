@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
 	"time"
 )
 
@@ -29,6 +30,10 @@ func (b *MongoFilterBuilder) BuildFilter() (bson.M, error) {
 			return nil, fmt.Errorf("invalid filter: field '%s' with operation '%s' is not allowed", f.Field, f.Operation)
 		}
 
+		if err := validateScalarValues(f); err != nil {
+			return nil, err
+		}
+
 		if err := b.applyFilter(filter, f); err != nil {
 			return nil, err
 		}
@@ -63,20 +68,54 @@ func (b *MongoFilterBuilder) applyFilter(filter bson.M, f Filter) error {
 			"$lte": f.ValueTo,
 		}
 	case "contains":
+		value, ok := f.Value.(string)
+		if !ok {
+			return fmt.Errorf("invalid filter: field '%s' with operation 'contains' requires a string value", f.Field)
+		}
+
 		filter[f.Field] = bson.M{
-			"$regex":   f.Value,
+			"$regex":   regexp.QuoteMeta(value),
 			"$options": "i",
 		}
 	case "equals":
 		filter[f.Field] = f.Value
 	case "gt":
-		filter[f.Field] = bson.M{"$gt": f.Value}
+		value, err := comparableValue(f.Value)
+		if err != nil {
+			return err
+		}
+		filter[f.Field] = bson.M{"$gt": value}
 	case "lt":
-		filter[f.Field] = bson.M{"$lt": f.Value}
+		value, err := comparableValue(f.Value)
+		if err != nil {
+			return err
+		}
+		filter[f.Field] = bson.M{"$lt": value}
 	}
 	return nil
 }
 
+// validateScalarValues rejects objects and arrays, which would otherwise
+// reach MongoDB as query operators such as {"$ne": null}.
+func validateScalarValues(f Filter) error {
+	for _, value := range []interface{}{f.Value, f.ValueFrom, f.ValueTo} {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return fmt.Errorf("invalid filter: field '%s' only accepts string, number or boolean values", f.Field)
+		}
+	}
+	return nil
+}
+
+// comparableValue turns DD-MM-YYYY strings into dates so that gt and lt
+// work on date fields the same way between does.
+func comparableValue(value interface{}) (interface{}, error) {
+	if str, ok := value.(string); ok && isDateFormat(str) {
+		return parseDate(str)
+	}
+	return value, nil
+}
+
 func isDateFormat(value string) bool {
 	if _, err := time.Parse("02-01-2006", value); err == nil {
 		return true
@@ -109,7 +148,13 @@ func (b *MongoFilterBuilder) BuildFindOptions() *options.FindOptions {
 		if b.Request.SortOrder == "desc" {
 			order = -1
 		}
-		opts.SetSort(bson.D{{b.Request.SortBy, order}})
+		sort := bson.D{{Key: b.Request.SortBy, Value: order}}
+		// Skip and limit only page reliably over a total order, so documents
+		// with equal values are ordered by _id.
+		if b.Request.SortBy != "_id" {
+			sort = append(sort, bson.E{Key: "_id", Value: order})
+		}
+		opts.SetSort(sort)
 	}
 
 	return opts
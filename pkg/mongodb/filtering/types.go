@@ -19,6 +19,15 @@ type FilterMetadata struct {
 	SortableFields   []SortableField   `json:"sortableFields"`
 } //@name FilterMetadata
 
+func (m *FilterMetadata) IsSortable(field string) bool {
+	for _, sortable := range m.SortableFields {
+		if sortable.Name == field {
+			return true
+		}
+	}
+	return false
+}
+
 type PaginationInfo struct {
 	TotalCount  int `json:"totalCount"`
 	CurrentPage int `json:"currentPage"`
@@ -46,7 +55,7 @@ type BaseSortingRequest struct {
 type FilterRequest struct {
 	FiltersJSON string `form:"filters"`
 	SortBy      string `form:"sortBy"`
-	SortOrder   string `form:"sortOrder" binding:"omitempty,oneof=asc desc"`
+	SortOrder   string `form:"sortOrder"`
 	Page        int    `form:"page" binding:"omitempty,min=1"`
 	PageSize    int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
 }
@@ -243,6 +243,69 @@ func SetValue(db *MongoDB, collectionName string, id string, fieldName string, v
 	return err
 }
 
+// UpdateOneWithFilter applies update to the first document matching filter
+// and returns the number of matched documents.
+func UpdateOneWithFilter(db *MongoDB, collectionName string, filter interface{}, update interface{}) (int64, error) {
+	db.logMongo()
+	client, err := db.getClient()
+	if err != nil {
+		return 0, err
+	}
+
+	collection := client.Database(db.DBName).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.MatchedCount, nil
+}
+
+func UpdateMany(db *MongoDB, collectionName string, filter interface{}, update interface{}) (int64, error) {
+	db.logMongo()
+	client, err := db.getClient()
+	if err != nil {
+		return 0, err
+	}
+
+	collection := client.Database(db.DBName).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// FindOneAndUpdate returns nil without an error when no document matches the filter.
+func FindOneAndUpdate[T any](db *MongoDB, collectionName string, filter interface{}, update interface{}, opts *options.FindOneAndUpdateOptions) (*T, error) {
+	db.logMongo()
+	client, err := db.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	collection := client.Database(db.DBName).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var result *T
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	return result, err
+}
+
 func UpsertOne[T any](db *MongoDB, collectionName string, id string, record *T) error {
 	db.logMongo()
 	client, err := db.getClient()
@@ -441,6 +504,22 @@ func Count(db *MongoDB, collectionName string, filter interface{}, opts *options
 	}
 }
 
+func CreateIndexes(db *MongoDB, collectionName string, indexModels []mongo.IndexModel) error {
+	db.logMongo()
+	client, err := db.getClient()
+	if err != nil {
+		return err
+	}
+
+	collection := client.Database(db.DBName).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	_, err = collection.Indexes().CreateMany(ctx, indexModels)
+	return err
+}
+
 func Aggregate[T any](db *MongoDB, collectionName string, pipeline interface{}) ([]T, error) {
 	db.logMongo()
 	client, err := db.getClient()
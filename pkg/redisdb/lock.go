@@ -0,0 +1,49 @@
+package redisdb
+
+import (
+	"context"
+	"time"
+)
+
+const refreshLockScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+
+const releaseLockScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+
+// AcquireLock takes the lock when it is free, or extends the lease when owner
+// already holds it. It reports whether owner holds the lock afterwards.
+func (r *RedisDB) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := r.SetNX(ctx, key, owner, ttl)
+	if err != nil || acquired {
+		return acquired, err
+	}
+
+	result, err := r.Eval(ctx, refreshLockScript, []string{key}, owner, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	return result == int64(1), nil
+}
+
+// ReleaseLock deletes the lock only if owner still holds it.
+func (r *RedisDB) ReleaseLock(ctx context.Context, key, owner string) error {
+	_, err := r.Eval(ctx, releaseLockScript, []string{key}, owner)
+	return err
+}
+
+// LockOwner returns an empty string when nobody holds the lock.
+func (r *RedisDB) LockOwner(ctx context.Context, key string) (string, error) {
+	owner, err := r.Get(ctx, key)
+	if IsNotFound(err) {
+		return "", nil
+	}
+	return owner, err
+}
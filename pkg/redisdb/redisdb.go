@@ -3,6 +3,7 @@ package redisdb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/go-redis/redis/v8"
 	"time"
 )
@@ -81,7 +82,23 @@ func (r *RedisDB) SetWithExpiration(ctx context.Context, key string, value inter
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
+func (r *RedisDB) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
+
+func (r *RedisDB) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
+func (r *RedisDB) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return r.client.Eval(ctx, script, keys, args...).Result()
+}
+
 func (r *RedisDB) Exists(ctx context.Context, key string) (bool, error) {
 	result, err := r.client.Exists(ctx, key).Result()
 	return result > 0, err
 }
+
+func IsNotFound(err error) bool {
+	return errors.Is(err, redis.Nil)
+}
@@ -0,0 +1,113 @@
+package redisdb
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+)
+
+type StreamEntry struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// StreamAdd appends the entries to the stream in a single round trip.
+func (r *RedisDB) StreamAdd(ctx context.Context, stream string, entries []map[string]interface{}) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, values := range entries {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: stream,
+				Values: values,
+			})
+		}
+		return nil
+	})
+	return err
+}
+
+// EnsureConsumerGroup creates the stream and the consumer group unless the
+// group already exists.
+func (r *RedisDB) EnsureConsumerGroup(ctx context.Context, stream, group string) error {
+	err := r.client.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// StreamReadGroup reads up to count new entries for consumer, waiting at most
+// block for them to arrive. It returns no entries without an error on timeout.
+func (r *RedisDB) StreamReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]StreamEntry, error) {
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []StreamEntry
+	for _, s := range streams {
+		entries = append(entries, toStreamEntries(s.Messages)...)
+	}
+	return entries, nil
+}
+
+// StreamAutoClaim hands entries that were delivered to another consumer, but
+// not acknowledged within minIdle, over to consumer.
+func (r *RedisDB) StreamAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]StreamEntry, error) {
+	messages, _, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+		Consumer: consumer,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return toStreamEntries(messages), nil
+}
+
+// StreamAck acknowledges the entries and removes them from the stream.
+func (r *RedisDB) StreamAck(ctx context.Context, stream, group string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, stream, group, ids...)
+		pipe.XDel(ctx, stream, ids...)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisDB) StreamLength(ctx context.Context, stream string) (int64, error) {
+	return r.client.XLen(ctx, stream).Result()
+}
+
+func toStreamEntries(messages []redis.XMessage) []StreamEntry {
+	entries := make([]StreamEntry, len(messages))
+	for i, message := range messages {
+		entries[i] = StreamEntry{
+			ID:     message.ID,
+			Values: message.Values,
+		}
+	}
+	return entries
+}
@@ -0,0 +1,61 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// takeTokenScript refills the bucket for the time passed since the last call,
+// using the Redis server clock so that all clients agree, and takes a token
+// when one is available. It returns whether a token was taken and otherwise
+// how many milliseconds to wait for the next one. Times are kept in
+// milliseconds, which Lua numbers represent exactly.
+const takeTokenScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}`
+
+// TakeToken takes a token from the bucket at key, which holds up to burst
+// tokens and is refilled with rate tokens per second. When no token is left it
+// reports how long to wait before trying again.
+func (r *RedisDB) TakeToken(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	result, err := r.Eval(ctx, takeTokenScript, []string{key}, rate, burst)
+	if err != nil {
+		return false, 0, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected token bucket result: %v", result)
+	}
+
+	allowed, _ := values[0].(int64)
+	wait, _ := values[1].(int64)
+
+	return allowed == 1, time.Duration(wait) * time.Millisecond, nil
+}
@@ -0,0 +1,58 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// takeWindowSlotScript counts calls in a fixed window that starts with the
+// first call. Calls over the limit are not counted and get the time left in
+// the window in milliseconds.
+const takeWindowSlotScript = `
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+
+if count > tonumber(ARGV[1]) then
+	redis.call("DECR", KEYS[1])
+	return {0, redis.call("PTTL", KEYS[1])}
+end
+return {1, 0}`
+
+// TakeWindowSlot allows up to limit calls for key per window. When the limit
+// is reached it reports how long until the window ends.
+func (r *RedisDB) TakeWindowSlot(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	result, err := r.Eval(ctx, takeWindowSlotScript, []string{key}, limit, window.Milliseconds())
+	if err != nil {
+		return false, 0, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected window counter result: %v", result)
+	}
+
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(int64)
+	if allowed != 1 && remaining <= 0 {
+		remaining = window.Milliseconds()
+	}
+
+	return allowed == 1, time.Duration(remaining) * time.Millisecond, nil
+}
+
+const returnWindowSlotScript = `
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+if count > 0 then
+	return redis.call("DECR", KEYS[1])
+end
+return 0`
+
+// ReturnWindowSlot gives back a slot taken with TakeWindowSlot, e.g. for a
+// call that did not go through. The window itself is not extended.
+func (r *RedisDB) ReturnWindowSlot(ctx context.Context, key string) error {
+	_, err := r.Eval(ctx, returnWindowSlotScript, []string{key})
+	return err
+}